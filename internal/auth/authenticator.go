@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+var (
+	// ErrInvalidSession is returned when a token doesn't belong to an active session
+	ErrInvalidSession = errors.New("invalid session")
+	// ErrUnavailable is returned when the identity provider cannot be reached
+	ErrUnavailable = errors.New("authentication service unavailable")
+)
+
+// Authenticator resolves session tokens to the sessions they belong to
+type Authenticator interface {
+	// Authenticate returns the session for a token, or ErrInvalidSession
+	Authenticate(ctx context.Context, sessionToken string) (*kratosclient.Session, error)
+	// ListSessions returns the active sessions of an identity
+	ListSessions(ctx context.Context, identityID string) ([]kratosclient.Session, error)
+	// RevokeSession invalidates a session so its token stops authenticating
+	RevokeSession(ctx context.Context, sessionID string) error
+	// RevokeIdentitySessions invalidates every session of an identity
+	RevokeIdentitySessions(ctx context.Context, identityID string) error
+}
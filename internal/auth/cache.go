@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+// SessionCache keeps recently validated Kratos sessions in memory so that
+// repeated requests with the same credentials don't each round trip to Kratos.
+// Entries are keyed by a hash of the session token, never the token itself.
+type SessionCache struct {
+	entries *lru.Cache[string, cacheEntry]
+	ttl     time.Duration
+
+	// mu guards byIdentity and bySession, which index the cache keys by the
+	// identity and session they belong to so revoking doesn't scan the cache,
+	// and the times sessions and identities were last revoked, which keep
+	// validations that raced a revocation out of the cache
+	mu                sync.Mutex
+	byIdentity        map[string]map[string]struct{}
+	bySession         map[string]map[string]struct{}
+	revokedIdentities map[string]time.Time
+	revokedSessions   map[string]time.Time
+}
+
+type cacheEntry struct {
+	session   *kratosclient.Session
+	refreshAt time.Time
+	expiresAt time.Time
+}
+
+// NewSessionCache creates a cache holding at most size sessions. A cached
+// session is revalidated with Kratos once it is older than ttl, but is never
+// served past the session's own ExpiresAt.
+func NewSessionCache(size int, ttl time.Duration) (*SessionCache, error) {
+	c := &SessionCache{
+		ttl:               ttl,
+		byIdentity:        make(map[string]map[string]struct{}),
+		bySession:         make(map[string]map[string]struct{}),
+		revokedIdentities: make(map[string]time.Time),
+		revokedSessions:   make(map[string]time.Time),
+	}
+
+	entries, err := lru.NewWithEvict[string, cacheEntry](size, c.unindex)
+	if err != nil {
+		return nil, err
+	}
+	c.entries = entries
+
+	return c, nil
+}
+
+// Get returns the cached session for the token. fresh reports whether the
+// entry is still within its refresh window; a stale entry may still be used
+// when Kratos cannot be reached.
+func (c *SessionCache) Get(token string) (session *kratosclient.Session, fresh bool, ok bool) {
+	key := hashToken(token)
+
+	entry, ok := c.entries.Get(key)
+	if !ok {
+		return nil, false, false
+	}
+
+	now := time.Now()
+	if !now.Before(entry.expiresAt) {
+		c.entries.Remove(key)
+		return nil, false, false
+	}
+
+	return entry.session, now.Before(entry.refreshAt), true
+}
+
+// Add stores a session for the token that was validated at validatedAt.
+// Sessions revoked since then aren't stored, nor are validations older than
+// the refresh window.
+func (c *SessionCache) Add(token string, session *kratosclient.Session, validatedAt time.Time) {
+	if session == nil || (session.Active != nil && !*session.Active) {
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(validatedAt) > c.ttl {
+		return
+	}
+	entry := cacheEntry{
+		session:   session,
+		refreshAt: now.Add(c.ttl),
+		expiresAt: now.Add(c.ttl),
+	}
+
+	// Sessions without an expiry are only trusted for a single refresh window
+	if session.ExpiresAt != nil {
+		if !now.Before(*session.ExpiresAt) {
+			return
+		}
+		entry.expiresAt = *session.ExpiresAt
+		if entry.refreshAt.After(entry.expiresAt) {
+			entry.refreshAt = entry.expiresAt
+		}
+	}
+
+	key := hashToken(token)
+	if previous, ok := c.entries.Peek(key); ok {
+		c.unindex(key, previous)
+	}
+	c.index(key, entry)
+	c.entries.Add(key, entry)
+
+	// Checked only once the entry is indexed and stored: a revocation either
+	// happened before this check or finds the entry when it evicts
+	if c.revokedSince(session, validatedAt) {
+		c.entries.Remove(key)
+	}
+}
+
+// revokedSince reports whether the session or its identity was revoked at or
+// after t
+func (c *SessionCache) revokedSince(session *kratosclient.Session, t time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if revokedAt, ok := c.revokedSessions[session.Id]; ok && !revokedAt.Before(t) {
+		return true
+	}
+	if session.Identity != nil {
+		if revokedAt, ok := c.revokedIdentities[session.Identity.Id]; ok && !revokedAt.Before(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Invalidate drops the cached session for the token, e.g. on logout
+func (c *SessionCache) Invalidate(token string) {
+	c.entries.Remove(hashToken(token))
+}
+
+// InvalidateSession drops the cached entries of a session by its ID and
+// records it as revoked, so validations already in flight aren't cached
+func (c *SessionCache) InvalidateSession(sessionID string) {
+	c.invalidateIndexed(c.bySession, c.revokedSessions, sessionID)
+}
+
+// InvalidateIdentity drops every cached session belonging to the identity and
+// records them as revoked, so validations already in flight aren't cached
+func (c *SessionCache) InvalidateIdentity(identityID string) {
+	c.invalidateIndexed(c.byIdentity, c.revokedIdentities, identityID)
+}
+
+// invalidateIndexed records id as revoked and drops the entries an index
+// lists under it. Removing an entry unindexes it through the eviction
+// callback, so the keys are copied before the lock is released.
+func (c *SessionCache) invalidateIndexed(index map[string]map[string]struct{}, revoked map[string]time.Time, id string) {
+	c.mu.Lock()
+	now := time.Now()
+	// Validations older than the refresh window aren't cached anyway, so
+	// older revocations no longer need to be remembered
+	for revokedID, revokedAt := range revoked {
+		if now.Sub(revokedAt) > c.ttl {
+			delete(revoked, revokedID)
+		}
+	}
+	revoked[id] = now
+
+	keys := make([]string, 0, len(index[id]))
+	for key := range index[id] {
+		keys = append(keys, key)
+	}
+	c.mu.Unlock()
+
+	for _, key := range keys {
+		c.entries.Remove(key)
+	}
+}
+
+// index records which identity and session a cache key belongs to
+func (c *SessionCache) index(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	addKey(c.bySession, entry.session.Id, key)
+	if entry.session.Identity != nil {
+		addKey(c.byIdentity, entry.session.Identity.Id, key)
+	}
+}
+
+// unindex forgets a cache key once its entry is evicted or removed
+func (c *SessionCache) unindex(key string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removeKey(c.bySession, entry.session.Id, key)
+	if entry.session.Identity != nil {
+		removeKey(c.byIdentity, entry.session.Identity.Id, key)
+	}
+}
+
+func addKey(index map[string]map[string]struct{}, id, key string) {
+	keys, ok := index[id]
+	if !ok {
+		keys = make(map[string]struct{})
+		index[id] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+func removeKey(index map[string]map[string]struct{}, id, key string) {
+	delete(index[id], key)
+	if len(index[id]) == 0 {
+		delete(index, id)
+	}
+}
+
+// hashToken derives the cache key for a session token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var _ Authenticator = (*CachingAuthenticator)(nil)
+
+// CachingAuthenticator wraps an Authenticator with a SessionCache
+type CachingAuthenticator struct {
+	next  Authenticator
+	cache *SessionCache
+}
+
+// NewCachingAuthenticator serves sessions from cache when possible and only
+// falls through to next on a miss or once the cached entry needs refreshing
+func NewCachingAuthenticator(next Authenticator, cache *SessionCache) *CachingAuthenticator {
+	return &CachingAuthenticator{
+		next:  next,
+		cache: cache,
+	}
+}
+
+// Authenticate resolves the session for a token, preferring the cache. When
+// the underlying authenticator is unavailable a stale but unexpired cached
+// session is accepted so that short outages don't log everyone out.
+func (a *CachingAuthenticator) Authenticate(ctx context.Context, sessionToken string) (*kratosclient.Session, error) {
+	cached, fresh, ok := a.cache.Get(sessionToken)
+	if ok && fresh {
+		return cached, nil
+	}
+
+	validatedAt := time.Now()
+	session, err := a.next.Authenticate(ctx, sessionToken)
+	if err != nil {
+		if errors.Is(err, ErrUnavailable) && ok {
+			return cached, nil
+		}
+		a.cache.Invalidate(sessionToken)
+		return nil, err
+	}
+
+	a.cache.Add(sessionToken, session, validatedAt)
+	return session, nil
+}
+
+// ListSessions always asks the underlying authenticator
+func (a *CachingAuthenticator) ListSessions(ctx context.Context, identityID string) ([]kratosclient.Session, error) {
+	return a.next.ListSessions(ctx, identityID)
+}
+
+// RevokeSession revokes the session upstream and evicts it from the cache.
+// It is evicted again once the upstream call returns, which also keeps
+// validations that started before then out of the cache.
+func (a *CachingAuthenticator) RevokeSession(ctx context.Context, sessionID string) error {
+	a.cache.InvalidateSession(sessionID)
+	defer a.cache.InvalidateSession(sessionID)
+	return a.next.RevokeSession(ctx, sessionID)
+}
+
+// RevokeIdentitySessions revokes the identity's sessions upstream and evicts
+// them from the cache, before and again after the upstream call
+func (a *CachingAuthenticator) RevokeIdentitySessions(ctx context.Context, identityID string) error {
+	a.cache.InvalidateIdentity(identityID)
+	defer a.cache.InvalidateIdentity(identityID)
+	return a.next.RevokeIdentitySessions(ctx, identityID)
+}
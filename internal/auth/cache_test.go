@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+// pausingAuthenticator validates sessions with a FakeAuthenticator but holds
+// the result back until released, standing in for a slow Kratos round trip
+type pausingAuthenticator struct {
+	*FakeAuthenticator
+	validated chan struct{}
+	release   chan struct{}
+}
+
+func (a *pausingAuthenticator) Authenticate(ctx context.Context, token string) (*kratosclient.Session, error) {
+	session, err := a.FakeAuthenticator.Authenticate(ctx, token)
+	a.validated <- struct{}{}
+	<-a.release
+	return session, err
+}
+
+func TestRevokedSessionsStayOutOfTheCache(t *testing.T) {
+	revokes := map[string]func(a *CachingAuthenticator, session *kratosclient.Session) error{
+		"session": func(a *CachingAuthenticator, session *kratosclient.Session) error {
+			return a.RevokeSession(context.Background(), session.Id)
+		},
+		"identity": func(a *CachingAuthenticator, session *kratosclient.Session) error {
+			return a.RevokeIdentitySessions(context.Background(), session.Identity.Id)
+		},
+	}
+
+	for name, revoke := range revokes {
+		t.Run(name, func(t *testing.T) {
+			fake := NewFakeAuthenticator()
+			token, session := fake.MintSession("identity", nil)
+
+			cache, err := NewSessionCache(10, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			upstream := &pausingAuthenticator{
+				FakeAuthenticator: fake,
+				validated:         make(chan struct{}, 1),
+				release:           make(chan struct{}),
+			}
+			authn := NewCachingAuthenticator(upstream, cache)
+
+			// A request validates the session just before it is revoked and
+			// only gets to cache it afterwards
+			done := make(chan error)
+			go func() {
+				_, err := authn.Authenticate(context.Background(), token)
+				done <- err
+			}()
+			<-upstream.validated
+			if err := revoke(authn, session); err != nil {
+				t.Fatal(err)
+			}
+			close(upstream.release)
+			if err := <-done; err != nil {
+				t.Fatalf("in-flight request failed: %v", err)
+			}
+
+			if _, _, ok := cache.Get(token); ok {
+				t.Fatal("revoked session was cached")
+			}
+			if _, err := authn.Authenticate(context.Background(), token); !errors.Is(err, ErrInvalidSession) {
+				t.Errorf("revoked session: err = %v, want %v", err, ErrInvalidSession)
+			}
+		})
+	}
+}
+
+func TestSessionsAreCachedWithoutRevocation(t *testing.T) {
+	fake := NewFakeAuthenticator()
+	token, session := fake.MintSession("identity", nil)
+
+	cache, err := NewSessionCache(10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn := NewCachingAuthenticator(fake, cache)
+
+	if _, err := authn.Authenticate(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+	if _, fresh, ok := cache.Get(token); !ok || !fresh {
+		t.Fatalf("session not cached: ok = %v, fresh = %v", ok, fresh)
+	}
+
+	// Revoking an unrelated session leaves this one cached
+	if err := authn.RevokeSession(context.Background(), "other"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := cache.Get(token); !ok {
+		t.Error("unrelated revocation evicted the session")
+	}
+
+	if err := authn.RevokeSession(context.Background(), session.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := cache.Get(token); ok {
+		t.Error("revoked session is still cached")
+	}
+}
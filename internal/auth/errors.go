@@ -0,0 +1,27 @@
+package auth
+
+// Error codes returned to clients for authentication and authorization failures
+const (
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeStepUpRequired   = "STEP_UP_REQUIRED"
+	CodeAccountSuspended = "ACCOUNT_SUSPENDED"
+)
+
+// Error is an authentication or authorization failure with a machine-readable
+// code and optional details telling the client how to recover
+type Error struct {
+	Code    string
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrNotAuthenticated is returned when a request carries no valid session
+var ErrNotAuthenticated = &Error{
+	Code:    CodeUnauthenticated,
+	Message: "not authenticated",
+}
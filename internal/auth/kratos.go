@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+var _ Authenticator = (*KratosAuthenticator)(nil)
+
+// maxListedSessions caps how many sessions ListSessions returns
+const maxListedSessions = 500
+
+// KratosAuthenticator validates sessions against the Kratos public API and
+// revokes them through the admin API
+type KratosAuthenticator struct {
+	public *kratosclient.APIClient
+	admin  *kratosclient.APIClient
+}
+
+// NewKratosAuthenticator creates an Authenticator backed by Kratos
+func NewKratosAuthenticator(cfg *config.Config) *KratosAuthenticator {
+	return &KratosAuthenticator{
+		public: newKratosClient(cfg.KratosPublicURL),
+		admin:  newKratosClient(cfg.KratosAdminURL),
+	}
+}
+
+// Authenticate checks if the session is valid with Kratos
+func (a *KratosAuthenticator) Authenticate(ctx context.Context, sessionToken string) (*kratosclient.Session, error) {
+	resp, r, err := a.public.FrontendAPI.ToSession(ctx).
+		Cookie("ory_kratos_session=" + sessionToken).
+		Execute()
+
+	if r == nil || r.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if err != nil || r.StatusCode != http.StatusOK {
+		return nil, ErrInvalidSession
+	}
+
+	return resp, nil
+}
+
+// ListSessions fetches the identity's active sessions from the admin API.
+// Users rarely hold more than a handful, so a single page is enough.
+func (a *KratosAuthenticator) ListSessions(ctx context.Context, identityID string) ([]kratosclient.Session, error) {
+	sessions, _, err := a.admin.IdentityAPI.ListIdentitySessions(ctx, identityID).
+		Active(true).
+		PageSize(maxListedSessions).
+		Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions of identity %s: %w", identityID, err)
+	}
+	return sessions, nil
+}
+
+// RevokeSession disables the session in Kratos
+func (a *KratosAuthenticator) RevokeSession(ctx context.Context, sessionID string) error {
+	if _, err := a.admin.IdentityAPI.DisableSession(ctx, sessionID).Execute(); err != nil {
+		return fmt.Errorf("failed to revoke session %s: %w", sessionID, err)
+	}
+	return nil
+}
+
+// RevokeIdentitySessions deletes every session of the identity in Kratos
+func (a *KratosAuthenticator) RevokeIdentitySessions(ctx context.Context, identityID string) error {
+	r, err := a.admin.IdentityAPI.DeleteIdentitySessions(ctx, identityID).Execute()
+	if err != nil && (r == nil || r.StatusCode != http.StatusNotFound) {
+		return fmt.Errorf("failed to revoke sessions of identity %s: %w", identityID, err)
+	}
+	return nil
+}
+
+// newKratosClient creates a Kratos API client for the given base URL
+func newKratosClient(url string) *kratosclient.APIClient {
+	return kratosclient.NewAPIClient(&kratosclient.Configuration{
+		Servers: []kratosclient.ServerConfiguration{
+			{
+				URL: url,
+			},
+		},
+	})
+}
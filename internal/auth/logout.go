@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+)
+
+// LogoutHandler revokes the caller's session so it stops being accepted
+// immediately, including by any session cache in front of Kratos.
+func LogoutHandler(authn Authenticator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		session, ok := GetUserFromContext(r.Context())
+		if !ok {
+			http.Error(w, "Not authenticated", http.StatusUnauthorized)
+			return
+		}
+
+		if err := authn.RevokeSession(r.Context(), session.Id); err != nil {
+			log.Printf("Logout failed: %v", err)
+			http.Error(w, "Failed to revoke session", http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -3,97 +3,112 @@ package auth
 import (
 	"context"
 	"errors"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
-	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
 	kratosclient "github.com/ory/kratos-client-go"
 )
 
 type contextKey string
 
 const (
-	userIDKey contextKey = "userID"
-	userKey   contextKey = "user"
+	userIDKey  contextKey = "userID"
+	userKey    contextKey = "user"
+	accountKey contextKey = "account"
+	scopesKey  contextKey = "scopes"
 )
 
-// Middleware validates the session and sets user info in context
-func Middleware(cfg *config.Config) func(http.Handler) http.Handler {
+// Middleware validates the session or personal access token and sets user
+// and account info in context
+func Middleware(authn Authenticator, tokens TokenVerifier, accounts AccountLoader) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Skip auth for certain paths like health check
-			if r.URL.Path == "/health" || r.URL.Path == "/playground" {
+			// Skip auth for certain paths like health check. Media URLs carry
+			// their own signature.
+			if r.URL.Path == "/health" || r.URL.Path == "/playground" || strings.HasPrefix(r.URL.Path, "/media/") {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			// Get session cookie
-			cookie, err := r.Cookie("ory_kratos_session")
+			token, err := sessionTokenFromRequest(r)
 			if err != nil {
-				// No session cookie found, check if this is an API call with bearer token
-				authHeader := r.Header.Get("Authorization")
-				if authHeader == "" {
-					// No authentication provided, let the resolver handle unauthorized access
-					next.ServeHTTP(w, r)
-					return
-				}
+				http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
+				return
+			}
+			if token == "" {
+				// No authentication provided, let the resolver handle unauthorized access
+				next.ServeHTTP(w, r)
+				return
+			}
 
-				// Extract bearer token
-				parts := strings.Split(authHeader, " ")
-				if len(parts) != 2 || parts[0] != "Bearer" {
-					http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
+			var userID string
+			var ctx context.Context
+			if strings.HasPrefix(token, PersonalAccessTokenPrefix) {
+				var scopes []Scope
+				userID, scopes, err = tokens.VerifyToken(r.Context(), token)
+				if err != nil {
+					if !errors.Is(err, ErrInvalidToken) {
+						log.Printf("Access token verification failed: %v", err)
+						http.Error(w, "Failed to verify access token", http.StatusInternalServerError)
+						return
+					}
+					http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
 					return
 				}
 
-				// Use the token to validate with Kratos
-				sessionToken := parts[1]
-				userInfo, err := validateSession(cfg, sessionToken)
+				ctx = context.WithValue(r.Context(), scopesKey, scopes)
+			} else {
+				userInfo, err := authn.Authenticate(r.Context(), token)
 				if err != nil {
+					if errors.Is(err, ErrUnavailable) {
+						log.Printf("Session validation failed: %v", err)
+						http.Error(w, "Authentication service unavailable", http.StatusServiceUnavailable)
+						return
+					}
 					http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
 					return
 				}
 
-				// Set user info in context
-				ctx := context.WithValue(r.Context(), userIDKey, userInfo.Identity.Id)
-				ctx = context.WithValue(ctx, userKey, userInfo)
-				next.ServeHTTP(w, r.WithContext(ctx))
-				return
+				userID = userInfo.Identity.Id
+				ctx = context.WithValue(r.Context(), userKey, userInfo)
 			}
 
-			// Validate session using cookie
-			userInfo, err := validateSession(cfg, cookie.Value)
+			account, err := accounts.LoadAccount(r.Context(), userID)
 			if err != nil {
-				http.Error(w, "Invalid or expired session", http.StatusUnauthorized)
+				log.Printf("Failed to load account %s: %v", userID, err)
+				http.Error(w, "Failed to load account", http.StatusInternalServerError)
 				return
 			}
 
 			// Set user info in context
-			ctx := context.WithValue(r.Context(), userIDKey, userInfo.Identity.Id)
-			ctx = context.WithValue(ctx, userKey, userInfo)
+			ctx = context.WithValue(ctx, userIDKey, userID)
+			ctx = context.WithValue(ctx, accountKey, account)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// validateSession checks if the session is valid with Kratos
-func validateSession(cfg *config.Config, sessionToken string) (*kratosclient.Session, error) {
-	client := kratosclient.NewAPIClient(&kratosclient.Configuration{
-		Servers: []kratosclient.ServerConfiguration{
-			{
-				URL: cfg.KratosPublicURL,
-			},
-		},
-	})
-
-	resp, r, err := client.FrontendAPI.ToSession(context.Background()).
-		Cookie("ory_kratos_session=" + sessionToken).
-		Execute()
-
-	if err != nil || r.StatusCode != 200 {
-		return nil, errors.New("invalid session")
+// sessionTokenFromRequest extracts the session token from the Kratos session
+// cookie or, for API clients, from a bearer token, which may also be a
+// personal access token. An empty token means the request carries no credentials.
+func sessionTokenFromRequest(r *http.Request) (string, error) {
+	if cookie, err := r.Cookie("ory_kratos_session"); err == nil {
+		return cookie.Value, nil
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", nil
+	}
+
+	parts := strings.Split(authHeader, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", errors.New("invalid authorization header")
 	}
 
-	return resp, nil
+	return parts[1], nil
 }
 
 // GetUserIDFromContext retrieves the user ID from context
@@ -108,11 +123,27 @@ func GetUserFromContext(ctx context.Context) (*kratosclient.Session, bool) {
 	return user, ok
 }
 
-// RequireAuth checks if the user is authenticated
+// RequireAuth checks if the user is authenticated and not suspended
 func RequireAuth(ctx context.Context) (string, error) {
 	userID, ok := GetUserIDFromContext(ctx)
 	if !ok || userID == "" {
-		return "", errors.New("not authenticated")
+		return "", ErrNotAuthenticated
+	}
+
+	if account, ok := GetAccountFromContext(ctx); ok && account.Suspension != nil {
+		details := map[string]interface{}{
+			"reason": account.Suspension.Reason,
+		}
+		if account.Suspension.ExpiresAt != nil {
+			details["expiresAt"] = account.Suspension.ExpiresAt.Format(time.RFC3339)
+		}
+
+		return "", &Error{
+			Code:    CodeAccountSuspended,
+			Message: "account suspended",
+			Details: details,
+		}
 	}
+
 	return userID, nil
 }
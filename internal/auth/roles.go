@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Role is the authorization level of a user
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// roleRank orders roles so that higher roles inherit lower privileges
+var roleRank = map[Role]int{
+	RoleUser:      1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// Valid reports whether r is a known role
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// Includes reports whether r grants at least the privileges of other
+func (r Role) Includes(other Role) bool {
+	return r.Valid() && roleRank[r] >= roleRank[other]
+}
+
+// Account is the local account state attached to an authenticated identity
+type Account struct {
+	Role       Role
+	Suspension *Suspension
+}
+
+// Suspension is the active suspension of an account. A nil ExpiresAt means
+// the account is banned until a moderator lifts it.
+type Suspension struct {
+	Reason    string
+	ExpiresAt *time.Time
+}
+
+// AccountLoader loads the local account of an authenticated identity
+type AccountLoader interface {
+	LoadAccount(ctx context.Context, userID string) (*Account, error)
+}
+
+// GetAccountFromContext retrieves the local account from context
+func GetAccountFromContext(ctx context.Context) (*Account, bool) {
+	account, ok := ctx.Value(accountKey).(*Account)
+	return account, ok
+}
+
+// RequireAccount checks that the user is authenticated like RequireAuth and
+// also returns their local account, for callers acting on the user's role
+func RequireAccount(ctx context.Context) (string, *Account, error) {
+	userID, err := RequireAuth(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	account, ok := GetAccountFromContext(ctx)
+	if !ok || account == nil {
+		return "", nil, ErrNotAuthenticated
+	}
+
+	return userID, account, nil
+}
+
+// RequireRole checks that the user is authenticated and holds at least role
+func RequireRole(ctx context.Context, role Role) (string, error) {
+	userID, account, err := RequireAccount(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if !account.Role.Includes(role) {
+		return "", &Error{
+			Code:    CodeForbidden,
+			Message: fmt.Sprintf("requires %s role", role),
+		}
+	}
+
+	return userID, nil
+}
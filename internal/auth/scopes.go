@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// PersonalAccessTokenPrefix marks bearer tokens that are personal access
+// tokens rather than Kratos session tokens
+const PersonalAccessTokenPrefix = "tsp_"
+
+// ErrInvalidToken is returned for unknown, revoked or expired access tokens
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Scope limits what a personal access token may be used for
+type Scope string
+
+const (
+	ScopeReadProfile  Scope = "read:profile"
+	ScopeWriteProfile Scope = "write:profile"
+	ScopeReadUsers    Scope = "read:users"
+)
+
+// Scopes lists every scope a personal access token can be granted
+var Scopes = []Scope{
+	ScopeReadProfile,
+	ScopeWriteProfile,
+	ScopeReadUsers,
+}
+
+// Valid reports whether s is a known scope
+func (s Scope) Valid() bool {
+	for _, scope := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// TokenVerifier resolves personal access tokens to their owner and scopes
+type TokenVerifier interface {
+	VerifyToken(ctx context.Context, token string) (userID string, scopes []Scope, err error)
+}
+
+// GetScopesFromContext retrieves the scopes of the personal access token the
+// request was authenticated with. ok is false for interactive sessions.
+func GetScopesFromContext(ctx context.Context) ([]Scope, bool) {
+	scopes, ok := ctx.Value(scopesKey).([]Scope)
+	return scopes, ok
+}
+
+// RequireScope checks that the caller may use something guarded by scope.
+// Interactive sessions hold every scope. Personal access tokens only hold the
+// scopes they were created with, and an empty scope means the operation is
+// limited to interactive sessions.
+func RequireScope(ctx context.Context, scope Scope) (string, error) {
+	userID, err := RequireAuth(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes, isToken := GetScopesFromContext(ctx)
+	if !isToken {
+		return userID, nil
+	}
+
+	if scope == "" {
+		return "", &Error{
+			Code:    CodeForbidden,
+			Message: "not available to personal access tokens",
+		}
+	}
+
+	for _, s := range scopes {
+		if s == scope {
+			return userID, nil
+		}
+	}
+
+	return "", &Error{
+		Code:    CodeForbidden,
+		Message: fmt.Sprintf("access token is missing the %s scope", scope),
+	}
+}
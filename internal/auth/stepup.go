@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+// aalRank orders authenticator assurance levels
+var aalRank = map[kratosclient.AuthenticatorAssuranceLevel]int{
+	kratosclient.AUTHENTICATORASSURANCELEVEL_AAL0: 0,
+	kratosclient.AUTHENTICATORASSURANCELEVEL_AAL1: 1,
+	kratosclient.AUTHENTICATORASSURANCELEVEL_AAL2: 2,
+	kratosclient.AUTHENTICATORASSURANCELEVEL_AAL3: 3,
+}
+
+// StepUp describes how strongly and how recently a session must have been
+// authenticated. Zero values don't constrain.
+type StepUp struct {
+	AAL    kratosclient.AuthenticatorAssuranceLevel
+	MaxAge time.Duration
+}
+
+// RequireStepUp checks the current session against the step-up requirement.
+// When it falls short the error details carry the query parameters for the
+// Kratos login flow that upgrades the session (refresh=true and aal).
+// Personal access tokens never qualify.
+func RequireStepUp(ctx context.Context, req StepUp) (string, error) {
+	userID, err := RequireAuth(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	session, _ := GetUserFromContext(ctx)
+	if session != nil && satisfiesAAL(session, req.AAL) && authenticatedWithin(session, req.MaxAge) {
+		return userID, nil
+	}
+
+	aal := req.AAL
+	if aal == "" {
+		aal = kratosclient.AUTHENTICATORASSURANCELEVEL_AAL1
+	}
+
+	flow := url.Values{}
+	flow.Set("refresh", "true")
+	flow.Set("aal", string(aal))
+
+	details := map[string]interface{}{
+		"requiredAal": string(aal),
+		"loginFlow":   flow.Encode(),
+	}
+	if req.MaxAge > 0 {
+		details["maxAgeMinutes"] = int(req.MaxAge / time.Minute)
+	}
+
+	return "", &Error{
+		Code:    CodeStepUpRequired,
+		Message: "please confirm your identity to continue",
+		Details: details,
+	}
+}
+
+// satisfiesAAL reports whether the session reached at least aal
+func satisfiesAAL(session *kratosclient.Session, aal kratosclient.AuthenticatorAssuranceLevel) bool {
+	if aal == "" {
+		return true
+	}
+	if session.AuthenticatorAssuranceLevel == nil {
+		return false
+	}
+	return aalRank[*session.AuthenticatorAssuranceLevel] >= aalRank[aal]
+}
+
+// authenticatedWithin reports whether the session was authenticated less
+// than maxAge ago
+func authenticatedWithin(session *kratosclient.Session, maxAge time.Duration) bool {
+	if maxAge <= 0 {
+		return true
+	}
+	return session.AuthenticatedAt != nil && time.Since(*session.AuthenticatedAt) <= maxAge
+}
@@ -3,14 +3,16 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	AppEnv  string
-	AppPort string
+	AppEnv        string
+	AppPort       string
+	PublicBaseURL string
 
 	DBHost     string
 	DBPort     string
@@ -20,6 +22,34 @@ type Config struct {
 
 	KratosPublicURL string
 	KratosAdminURL  string
+
+	KratosWebhookSecret  string
+	IdentitySyncInterval time.Duration
+
+	SessionCacheSize int
+	SessionCacheTTL  time.Duration
+
+	RecentAuthMaxAge           time.Duration
+	AccountDeletionGracePeriod time.Duration
+	AccountPurgeInterval       time.Duration
+
+	HandleChangeCooldown  time.Duration
+	ProfilePictureMaxSize int64
+
+	CurrentLocationMaxAge time.Duration
+	NearbyMaxRadiusKm     float64
+	SuggestionRadiusKm    float64
+
+	StorageDriver   string
+	StorageLocalDir string
+	S3Endpoint      string
+	S3Region        string
+	S3Bucket        string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3UseSSL        bool
+	MediaSigningKey string
+	MediaURLTTL     time.Duration
 }
 
 func LoadConfig() *Config {
@@ -33,6 +63,7 @@ func LoadConfig() *Config {
 	// Default values
 	viper.SetDefault("APP_ENV", "development")
 	viper.SetDefault("APP_PORT", "8080")
+	viper.SetDefault("PUBLIC_BASE_URL", "http://localhost:8080")
 	viper.SetDefault("DB_HOST", "localhost")
 	viper.SetDefault("DB_PORT", "5432")
 	viper.SetDefault("DB_USER", "postgres")
@@ -40,10 +71,27 @@ func LoadConfig() *Config {
 	viper.SetDefault("DB_NAME", "travel_social")
 	viper.SetDefault("ORY_KRATOS_PUBLIC_URL", "http://localhost:4433")
 	viper.SetDefault("ORY_KRATOS_ADMIN_URL", "http://localhost:4434")
+	viper.SetDefault("IDENTITY_SYNC_INTERVAL", "1h")
+	viper.SetDefault("SESSION_CACHE_SIZE", 10000)
+	viper.SetDefault("SESSION_CACHE_TTL", "1m")
+	viper.SetDefault("RECENT_AUTH_MAX_AGE", "15m")
+	viper.SetDefault("ACCOUNT_DELETION_GRACE_PERIOD", "720h")
+	viper.SetDefault("ACCOUNT_PURGE_INTERVAL", "1h")
+	viper.SetDefault("HANDLE_CHANGE_COOLDOWN", "720h")
+	viper.SetDefault("PROFILE_PICTURE_MAX_SIZE", 5<<20)
+	viper.SetDefault("CURRENT_LOCATION_MAX_AGE", "168h")
+	viper.SetDefault("NEARBY_MAX_RADIUS_KM", 500)
+	viper.SetDefault("SUGGESTION_RADIUS_KM", 100)
+	viper.SetDefault("STORAGE_DRIVER", "local")
+	viper.SetDefault("STORAGE_LOCAL_DIR", "./data/media")
+	viper.SetDefault("S3_REGION", "us-east-1")
+	viper.SetDefault("S3_USE_SSL", true)
+	viper.SetDefault("MEDIA_URL_TTL", "1h")
 
 	return &Config{
-		AppEnv:  viper.GetString("APP_ENV"),
-		AppPort: viper.GetString("APP_PORT"),
+		AppEnv:        viper.GetString("APP_ENV"),
+		AppPort:       viper.GetString("APP_PORT"),
+		PublicBaseURL: viper.GetString("PUBLIC_BASE_URL"),
 
 		DBHost:     viper.GetString("DB_HOST"),
 		DBPort:     viper.GetString("DB_PORT"),
@@ -53,6 +101,34 @@ func LoadConfig() *Config {
 
 		KratosPublicURL: viper.GetString("ORY_KRATOS_PUBLIC_URL"),
 		KratosAdminURL:  viper.GetString("ORY_KRATOS_ADMIN_URL"),
+
+		KratosWebhookSecret:  viper.GetString("KRATOS_WEBHOOK_SECRET"),
+		IdentitySyncInterval: viper.GetDuration("IDENTITY_SYNC_INTERVAL"),
+
+		SessionCacheSize: viper.GetInt("SESSION_CACHE_SIZE"),
+		SessionCacheTTL:  viper.GetDuration("SESSION_CACHE_TTL"),
+
+		RecentAuthMaxAge:           viper.GetDuration("RECENT_AUTH_MAX_AGE"),
+		AccountDeletionGracePeriod: viper.GetDuration("ACCOUNT_DELETION_GRACE_PERIOD"),
+		AccountPurgeInterval:       viper.GetDuration("ACCOUNT_PURGE_INTERVAL"),
+
+		HandleChangeCooldown:  viper.GetDuration("HANDLE_CHANGE_COOLDOWN"),
+		ProfilePictureMaxSize: viper.GetInt64("PROFILE_PICTURE_MAX_SIZE"),
+
+		CurrentLocationMaxAge: viper.GetDuration("CURRENT_LOCATION_MAX_AGE"),
+		NearbyMaxRadiusKm:     viper.GetFloat64("NEARBY_MAX_RADIUS_KM"),
+		SuggestionRadiusKm:    viper.GetFloat64("SUGGESTION_RADIUS_KM"),
+
+		StorageDriver:   viper.GetString("STORAGE_DRIVER"),
+		StorageLocalDir: viper.GetString("STORAGE_LOCAL_DIR"),
+		S3Endpoint:      viper.GetString("S3_ENDPOINT"),
+		S3Region:        viper.GetString("S3_REGION"),
+		S3Bucket:        viper.GetString("S3_BUCKET"),
+		S3AccessKey:     viper.GetString("S3_ACCESS_KEY"),
+		S3SecretKey:     viper.GetString("S3_SECRET_KEY"),
+		S3UseSSL:        viper.GetBool("S3_USE_SSL"),
+		MediaSigningKey: viper.GetString("MEDIA_SIGNING_KEY"),
+		MediaURLTTL:     viper.GetDuration("MEDIA_URL_TTL"),
 	}
 }
 
@@ -58,6 +58,198 @@ func initSchema(db *sql.DB) error {
 			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
 		)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'user'
+			CHECK (role IN ('user', 'moderator', 'admin'))`,
+		`CREATE TABLE IF NOT EXISTS personal_access_tokens (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			name VARCHAR(255) NOT NULL,
+			prefix VARCHAR(16) NOT NULL,
+			token_hash CHAR(64) NOT NULL UNIQUE,
+			scopes TEXT[] NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE,
+			last_used_at TIMESTAMP WITH TIME ZONE,
+			revoked_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS personal_access_tokens_user_id_idx ON personal_access_tokens (user_id)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMP WITH TIME ZONE`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_for TIMESTAMP WITH TIME ZONE`,
+		`CREATE TABLE IF NOT EXISTS user_suspensions (
+			id VARCHAR(36) PRIMARY KEY,
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			reason TEXT NOT NULL,
+			issued_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+			expires_at TIMESTAMP WITH TIME ZONE,
+			lifted_at TIMESTAMP WITH TIME ZONE,
+			lifted_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS user_suspensions_user_id_idx ON user_suspensions (user_id, created_at DESC)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(30)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS handle_changed_at TIMESTAMP WITH TIME ZONE`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS users_handle_idx ON users (LOWER(handle))`,
+		`CREATE TABLE IF NOT EXISTS handle_redirects (
+			handle VARCHAR(30) NOT NULL,
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS handle_redirects_handle_idx ON handle_redirects (LOWER(handle))`,
+		`CREATE TABLE IF NOT EXISTS privacy_settings (
+			user_id VARCHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			email VARCHAR(20) NOT NULL CHECK (email IN ('public', 'followers', 'only_me')),
+			last_name VARCHAR(20) NOT NULL CHECK (last_name IN ('public', 'followers', 'only_me')),
+			bio VARCHAR(20) NOT NULL CHECK (bio IN ('public', 'followers', 'only_me')),
+			interests VARCHAR(20) NOT NULL CHECK (interests IN ('public', 'followers', 'only_me')),
+			travel_preferences VARCHAR(20) NOT NULL CHECK (travel_preferences IN ('public', 'followers', 'only_me')),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_picture_key TEXT`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector TSVECTOR`,
+		`CREATE INDEX IF NOT EXISTS users_search_vector_idx ON users USING GIN (search_vector)`,
+		// Only fields the user's privacy settings make public are indexed, so
+		// search can't reveal hidden values. Every user gets a privacy_settings
+		// row holding the defaults from internal/user when they are provisioned,
+		// so there are no defaults here; a missing row keeps the fields out.
+		`CREATE OR REPLACE FUNCTION refresh_user_search_vector(target VARCHAR) RETURNS VOID AS $$
+			UPDATE users SET search_vector =
+				setweight(to_tsvector('simple', concat_ws(' ',
+					u.first_name,
+					u.handle,
+					CASE WHEN ps.last_name = 'public' THEN u.last_name END
+				)), 'A') ||
+				setweight(to_tsvector('simple', concat_ws(' ',
+					CASE WHEN ps.interests = 'public' THEN array_to_string(u.interests, ' ') END,
+					CASE WHEN ps.travel_preferences = 'public' THEN array_to_string(tp.languages_spoken, ' ') END
+				)), 'B') ||
+				setweight(to_tsvector('simple',
+					CASE WHEN ps.bio = 'public' THEN COALESCE(u.bio, '') ELSE '' END
+				), 'C')
+			FROM users u
+			LEFT JOIN privacy_settings ps ON ps.user_id = u.id
+			LEFT JOIN travel_preferences tp ON tp.user_id = u.id
+			WHERE users.id = target AND u.id = target
+		$$ LANGUAGE SQL`,
+		`CREATE OR REPLACE FUNCTION refresh_user_search_vector_trigger() RETURNS TRIGGER AS $$
+		BEGIN
+			PERFORM refresh_user_search_vector(COALESCE(to_jsonb(NEW)->>'user_id', to_jsonb(NEW)->>'id'));
+			RETURN NULL;
+		END
+		$$ LANGUAGE plpgsql`,
+		`CREATE OR REPLACE TRIGGER users_search_vector_refresh
+			AFTER INSERT OR UPDATE OF first_name, last_name, handle, bio, interests ON users
+			FOR EACH ROW EXECUTE FUNCTION refresh_user_search_vector_trigger()`,
+		`CREATE OR REPLACE TRIGGER travel_preferences_search_vector_refresh
+			AFTER INSERT OR UPDATE OF languages_spoken ON travel_preferences
+			FOR EACH ROW EXECUTE FUNCTION refresh_user_search_vector_trigger()`,
+		`CREATE OR REPLACE TRIGGER privacy_settings_search_vector_refresh
+			AFTER INSERT OR UPDATE ON privacy_settings
+			FOR EACH ROW EXECUTE FUNCTION refresh_user_search_vector_trigger()`,
+		`SELECT refresh_user_search_vector(id) FROM users WHERE search_vector IS NULL`,
+		// Each user has at most one travel_preferences row. Older trees could
+		// insert a second one concurrently, so keep only the latest before
+		// enforcing it.
+		`DELETE FROM travel_preferences tp USING travel_preferences newer
+			WHERE tp.user_id = newer.user_id AND (tp.updated_at, tp.id) < (newer.updated_at, newer.id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS travel_preferences_user_id_key ON travel_preferences (user_id)`,
+		`DROP INDEX IF EXISTS travel_preferences_user_id_idx`,
+		`CREATE INDEX IF NOT EXISTS travel_preferences_travel_style_idx ON travel_preferences (travel_style)`,
+		`CREATE INDEX IF NOT EXISTS travel_preferences_languages_spoken_idx ON travel_preferences USING GIN (languages_spoken)`,
+		`CREATE INDEX IF NOT EXISTS travel_preferences_preferred_activities_idx ON travel_preferences USING GIN (preferred_activities)`,
+		`CREATE INDEX IF NOT EXISTS users_interests_idx ON users USING GIN (interests)`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS users_first_name_trgm_idx ON users USING GIN (first_name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS users_last_name_trgm_idx ON users USING GIN (last_name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS users_handle_trgm_idx ON users USING GIN (handle gin_trgm_ops)`,
+		`ALTER TABLE users ADD COLUMN IF NOT EXISTS home_city_id VARCHAR(64)`,
+		// Only coarsened coordinates are stored, and a row exists only while
+		// the user shares their current location
+		`CREATE TABLE IF NOT EXISTS user_locations (
+			user_id VARCHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+			latitude DOUBLE PRECISION NOT NULL CHECK (latitude BETWEEN -90 AND 90),
+			longitude DOUBLE PRECISION NOT NULL CHECK (longitude BETWEEN -180 AND 180),
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS user_locations_bounding_box_idx ON user_locations(latitude, longitude)`,
+		`CREATE TABLE IF NOT EXISTS follows (
+			follower_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			followee_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (follower_id, followee_id),
+			CHECK (follower_id <> followee_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS follows_follower_idx ON follows(follower_id, created_at DESC, followee_id)`,
+		`CREATE INDEX IF NOT EXISTS follows_followee_idx ON follows(followee_id, created_at DESC, follower_id)`,
+		`CREATE TABLE IF NOT EXISTS friend_requests (
+			id VARCHAR(36) PRIMARY KEY,
+			sender_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			recipient_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'cancelled')),
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			resolved_at TIMESTAMP WITH TIME ZONE,
+			CHECK (sender_id <> recipient_id)
+		)`,
+		// At most one pending request between two users, whoever sent it
+		`CREATE UNIQUE INDEX IF NOT EXISTS friend_requests_pending_pair_idx ON friend_requests (
+			LEAST(sender_id, recipient_id), GREATEST(sender_id, recipient_id)
+		) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS friend_requests_sender_idx ON friend_requests(sender_id, created_at DESC, id) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS friend_requests_recipient_idx ON friend_requests(recipient_id, created_at DESC, id) WHERE status = 'pending'`,
+		// Friendships are symmetric and stored in both directions, so a user's
+		// friends are always found through user_id
+		`CREATE TABLE IF NOT EXISTS friendships (
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			friend_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, friend_id),
+			CHECK (user_id <> friend_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS friendships_user_idx ON friendships(user_id, created_at DESC, friend_id)`,
+		`CREATE TABLE IF NOT EXISTS user_blocks (
+			blocker_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			blocked_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (blocker_id, blocked_id),
+			CHECK (blocker_id <> blocked_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS user_blocks_blocked_idx ON user_blocks(blocked_id, blocker_id)`,
+		`CREATE TABLE IF NOT EXISTS user_mutes (
+			muter_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			muted_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (muter_id, muted_id),
+			CHECK (muter_id <> muted_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS suggestion_dismissals (
+			user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			dismissed_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (user_id, dismissed_id),
+			CHECK (user_id <> dismissed_id)
+		)`,
+		`CREATE INDEX IF NOT EXISTS users_home_city_id_idx ON users(home_city_id)`,
+		`CREATE TABLE IF NOT EXISTS user_reports (
+			id VARCHAR(36) PRIMARY KEY,
+			reporter_id VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+			reported_id VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+			category VARCHAR(30) NOT NULL CHECK (category IN (
+				'spam', 'harassment', 'hate_speech', 'inappropriate_content', 'impersonation', 'scam', 'other'
+			)),
+			details TEXT,
+			snapshot JSONB NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'in_review', 'actioned', 'dismissed')),
+			assigned_to VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+			action VARCHAR(20) CHECK (action IN ('dismiss', 'warn', 'suspend', 'remove_content')),
+			action_note TEXT,
+			suspension_id VARCHAR(36) REFERENCES user_suspensions(id) ON DELETE SET NULL,
+			resolved_by VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+			resolved_at TIMESTAMP WITH TIME ZONE,
+			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)`,
+		`CREATE INDEX IF NOT EXISTS user_reports_status_idx ON user_reports(status, created_at, id)`,
+		`CREATE INDEX IF NOT EXISTS user_reports_reported_idx ON user_reports(reported_id, created_at, id)`,
+		`CREATE UNIQUE INDEX IF NOT EXISTS user_reports_open_idx ON user_reports(reporter_id, reported_id)
+			WHERE status IN ('open', 'in_review')`,
 	}
 
 	for _, query := range queries {
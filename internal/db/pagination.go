@@ -0,0 +1,148 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+const (
+	// DefaultPageSize is the page size used when a connection field's first
+	// argument is omitted
+	DefaultPageSize = 20
+	// MaxPageSize is the largest page a client may request
+	MaxPageSize = 100
+)
+
+// ErrInvalidCursor is returned for cursors that weren't issued by CollectPage
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageArgs are the forward pagination arguments of a Relay connection field
+type PageArgs struct {
+	First *int
+	After *string
+}
+
+// Limit returns the number of items to return, validating First
+func (a PageArgs) Limit() (int, error) {
+	if a.First == nil {
+		return DefaultPageSize, nil
+	}
+	if *a.First < 0 || *a.First > MaxPageSize {
+		return 0, fmt.Errorf("first must be between 0 and %d", MaxPageSize)
+	}
+	return *a.First, nil
+}
+
+// DecodeAfter decodes the After cursor, if any, into the keys it was encoded
+// from. Keys must be decoded in the order the keyset query sorts by; without
+// a cursor they are left untouched.
+func (a PageArgs) DecodeAfter(keys ...interface{}) error {
+	if a.After == nil {
+		return nil
+	}
+	return DecodeCursor(*a.After, keys...)
+}
+
+// EncodeCursor builds an opaque cursor from the sort keys of a row
+func EncodeCursor(keys ...interface{}) string {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		// Keys are plain scalars, which always marshal
+		panic(fmt.Sprintf("failed to encode cursor: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads the sort keys encoded in a cursor into keys
+func DecodeCursor(cursor string, keys ...interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil || len(values) != len(keys) {
+		return ErrInvalidCursor
+	}
+	for i, value := range values {
+		if err := json.Unmarshal(value, keys[i]); err != nil {
+			return ErrInvalidCursor
+		}
+	}
+	return nil
+}
+
+// Page is one page of a keyset paginated query
+type Page[T any] struct {
+	Items           []T
+	Cursors         []string
+	HasPreviousPage bool
+	HasNextPage     bool
+}
+
+// CollectPage reads a page from rows selected with LIMIT limit+1, the extra
+// row only serving to tell whether there is a next page. scan returns each
+// item together with the sort keys its cursor is built from.
+func CollectPage[T any](rows *sql.Rows, args PageArgs, limit int, scan func(rows *sql.Rows) (T, []interface{}, error)) (*Page[T], error) {
+	page := &Page[T]{
+		Items:           []T{},
+		Cursors:         []string{},
+		HasPreviousPage: args.After != nil,
+	}
+
+	for rows.Next() {
+		if len(page.Items) == limit {
+			page.HasNextPage = true
+			break
+		}
+
+		item, keys, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+		page.Cursors = append(page.Cursors, EncodeCursor(keys...))
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// PageSlice pages through items that are already held in memory, for short
+// lists that come from outside the database. Cursors are positions in items,
+// so items must be in the same order for every page.
+func PageSlice[T any](items []T, args PageArgs) (*Page[T], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if args.After != nil {
+		var after int
+		if err := DecodeCursor(*args.After, &after); err != nil {
+			return nil, err
+		}
+		if after < 0 {
+			return nil, ErrInvalidCursor
+		}
+		start = min(after+1, len(items))
+	}
+	end := min(start+limit, len(items))
+
+	page := &Page[T]{
+		Items:           items[start:end],
+		Cursors:         make([]string, 0, end-start),
+		HasPreviousPage: args.After != nil,
+		HasNextPage:     end < len(items),
+	}
+	for i := start; i < end; i++ {
+		page.Cursors = append(page.Cursors, EncodeCursor(i))
+	}
+	return page, nil
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 17, 9, 30, 0, 123456789, time.UTC)
+	cursor := EncodeCursor(createdAt, "2f1c7a52-3b9e-4d1f-9a51-0c1b2d3e4f50", 42.5)
+
+	var gotCreatedAt *time.Time
+	var gotID string
+	var gotScore float64
+	if err := DecodeCursor(cursor, &gotCreatedAt, &gotID, &gotScore); err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if gotCreatedAt == nil || !gotCreatedAt.Equal(createdAt) {
+		t.Errorf("created at = %v, want %v", gotCreatedAt, createdAt)
+	}
+	if gotID != "2f1c7a52-3b9e-4d1f-9a51-0c1b2d3e4f50" {
+		t.Errorf("id = %q", gotID)
+	}
+	if gotScore != 42.5 {
+		t.Errorf("score = %v", gotScore)
+	}
+}
+
+func TestDecodeCursorRejectsTamperedInput(t *testing.T) {
+	valid := EncodeCursor(time.Date(2024, 5, 17, 9, 30, 0, 0, time.UTC), "id")
+	raw, _ := base64.RawURLEncoding.DecodeString(valid)
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"empty", ""},
+		{"not base64", "%%%not-base64%%%"},
+		{"padded base64", valid + "=="},
+		{"standard base64", base64.StdEncoding.EncodeToString(append(raw, '?', '?'))},
+		{"truncated", valid[:len(valid)-3]},
+		{"not json", encode("hello")},
+		{"not a list", encode(`{"created_at": "2024-01-01T00:00:00Z"}`)},
+		{"too few keys", encode(`["2024-01-01T00:00:00Z"]`)},
+		{"too many keys", encode(`["2024-01-01T00:00:00Z", "id", "extra"]`)},
+		{"wrong key type", encode(`["2024-01-01T00:00:00Z", 7]`)},
+		{"bad timestamp", encode(`["yesterday", "id"]`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var createdAt *time.Time
+			var id string
+			if err := DecodeCursor(tt.cursor, &createdAt, &id); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodeCursor(%q) = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
+
+func TestPageArgsLimit(t *testing.T) {
+	intPtr := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		first   *int
+		want    int
+		wantErr bool
+	}{
+		{"default", nil, DefaultPageSize, false},
+		{"zero", intPtr(0), 0, false},
+		{"max", intPtr(MaxPageSize), MaxPageSize, false},
+		{"negative", intPtr(-1), 0, true},
+		{"too large", intPtr(MaxPageSize + 1), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PageArgs{First: tt.first}.Limit()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Limit() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Limit() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageSlice(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	first := 2
+
+	var seen []string
+	var after *string
+	for pages := 0; ; pages++ {
+		if pages > len(items) {
+			t.Fatal("pagination didn't terminate")
+		}
+
+		page, err := PageSlice(items, PageArgs{First: &first, After: after})
+		if err != nil {
+			t.Fatalf("PageSlice: %v", err)
+		}
+		if page.HasPreviousPage != (after != nil) {
+			t.Errorf("page %d: HasPreviousPage = %v", pages, page.HasPreviousPage)
+		}
+		if len(page.Cursors) != len(page.Items) {
+			t.Fatalf("page %d: %d cursors for %d items", pages, len(page.Cursors), len(page.Items))
+		}
+		seen = append(seen, page.Items...)
+
+		if !page.HasNextPage {
+			break
+		}
+		after = &page.Cursors[len(page.Cursors)-1]
+	}
+
+	if len(seen) != len(items) {
+		t.Fatalf("saw %v, want %v", seen, items)
+	}
+	for i := range items {
+		if seen[i] != items[i] {
+			t.Fatalf("saw %v, want %v", seen, items)
+		}
+	}
+
+	negative := EncodeCursor(-1)
+	if _, err := PageSlice(items, PageArgs{After: &negative}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("negative cursor: err = %v, want ErrInvalidCursor", err)
+	}
+
+	past := EncodeCursor(100)
+	page, err := PageSlice(items, PageArgs{After: &past})
+	if err != nil {
+		t.Fatalf("cursor past the end: %v", err)
+	}
+	if len(page.Items) != 0 || page.HasNextPage {
+		t.Errorf("cursor past the end: got %v, HasNextPage %v", page.Items, page.HasNextPage)
+	}
+}
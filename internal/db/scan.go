@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const (
+	// UniqueViolation is the Postgres error code for a unique constraint violation
+	UniqueViolation = "23505"
+	// ForeignKeyViolation is the Postgres error code for a foreign key violation
+	ForeignKeyViolation = "23503"
+)
+
+// Querier is implemented by both *sql.DB and *sql.Tx, so repository code can
+// run on its own or as part of a caller's transaction
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// RowScanner is implemented by both *sql.Row and *sql.Rows
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// extraScanner scans columns selected after a fixed column list into extra
+type extraScanner struct {
+	row   RowScanner
+	extra []interface{}
+}
+
+func (s extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// WithExtra wraps row so that columns selected after a fixed column list,
+// such as the sort keys of a paginated query, are scanned into extra
+func WithExtra(row RowScanner, extra ...interface{}) RowScanner {
+	return extraScanner{row: row, extra: extra}
+}
@@ -0,0 +1,141 @@
+package geo
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// citiesCSV is an offline gazetteer of major cities and travel destinations
+// with the columns id, name, ascii_name, country, latitude, longitude.
+// Country is an ISO 3166-1 alpha-2 code. IDs are stored on profiles, so an
+// existing ID must never be changed or reused.
+//
+//go:embed cities.csv
+var citiesCSV []byte
+
+// City is an entry in the gazetteer
+type City struct {
+	ID      string
+	Name    string
+	Country string
+	Point
+
+	// asciiName is the name without diacritics, for matching queries typed without them
+	asciiName string
+}
+
+type gazetteer struct {
+	cities []*City
+	byID   map[string]*City
+}
+
+// loadGazetteer parses the embedded gazetteer once, on first use
+var loadGazetteer = sync.OnceValue(func() *gazetteer {
+	records, err := csv.NewReader(bytes.NewReader(citiesCSV)).ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("geo: invalid gazetteer: %v", err))
+	}
+
+	g := &gazetteer{byID: make(map[string]*City, len(records))}
+	for i, record := range records[1:] {
+		lat, latErr := strconv.ParseFloat(record[4], 64)
+		lng, lngErr := strconv.ParseFloat(record[5], 64)
+		if latErr != nil || lngErr != nil {
+			panic(fmt.Sprintf("geo: invalid coordinates on gazetteer line %d", i+2))
+		}
+
+		city := &City{
+			ID:        record[0],
+			Name:      record[1],
+			asciiName: record[2],
+			Country:   record[3],
+			Point:     Point{Latitude: lat, Longitude: lng},
+		}
+		g.cities = append(g.cities, city)
+		g.byID[city.ID] = city
+	}
+	return g
+})
+
+// LookupCity returns the city with the given ID
+func LookupCity(id string) (*City, bool) {
+	city, ok := loadGazetteer().byID[id]
+	return city, ok
+}
+
+// SearchCities resolves a place name to at most limit cities. Names that
+// match the query exactly come first, then names starting with it, then
+// names with a later word starting with it. Case and diacritics are ignored.
+func SearchCities(query string, limit int) []*City {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || limit <= 0 {
+		return []*City{}
+	}
+
+	type match struct {
+		city  *City
+		score int
+	}
+	var matches []match
+	for _, city := range loadGazetteer().cities {
+		score := 0
+		for _, name := range []string{strings.ToLower(city.Name), strings.ToLower(city.asciiName)} {
+			switch {
+			case name == query:
+				score = max(score, 3)
+			case strings.HasPrefix(name, query):
+				score = max(score, 2)
+			case strings.Contains(name, " "+query) || strings.Contains(name, "-"+query):
+				score = max(score, 1)
+			}
+		}
+		if score > 0 {
+			matches = append(matches, match{city, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].city.Name < matches[j].city.Name
+	})
+
+	cities := make([]*City, 0, min(limit, len(matches)))
+	for _, m := range matches[:min(limit, len(matches))] {
+		cities = append(cities, m.city)
+	}
+	return cities
+}
+
+// CitiesWithin returns the cities within radiusKm of center, nearest first,
+// along with their distances from it
+func CitiesWithin(center Point, radiusKm float64) ([]*City, []float64) {
+	type nearby struct {
+		city     *City
+		distance float64
+	}
+	var found []nearby
+	for _, city := range loadGazetteer().cities {
+		if d := Distance(center, city.Point); d <= radiusKm {
+			found = append(found, nearby{city, d})
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].distance < found[j].distance
+	})
+
+	cities := make([]*City, len(found))
+	distances := make([]float64, len(found))
+	for i, n := range found {
+		cities[i], distances[i] = n.city, n.distance
+	}
+	return cities, distances
+}
@@ -0,0 +1,98 @@
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadiusKm is the mean radius of the Earth
+const EarthRadiusKm = 6371.0
+
+// coarseScale sets the precision locations are rounded to: 1/100 of a degree,
+// about 1.1 km of latitude
+const coarseScale = 100
+
+// ErrInvalidPoint is returned for coordinates outside the valid ranges
+var ErrInvalidPoint = errors.New("latitude must be between -90 and 90 and longitude between -180 and 180")
+
+// Point is a position in decimal degrees
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Validate checks that the point lies within the valid coordinate ranges
+func (p Point) Validate() error {
+	if math.IsNaN(p.Latitude) || math.IsNaN(p.Longitude) ||
+		p.Latitude < -90 || p.Latitude > 90 || p.Longitude < -180 || p.Longitude > 180 {
+		return ErrInvalidPoint
+	}
+	return nil
+}
+
+// Coarsen snaps the point to a grid of about a kilometre, so a stored
+// location never reveals more than the neighbourhood someone is in. Snapping
+// rather than adding random noise means repeated updates from the same spot
+// can't be averaged to recover it.
+func (p Point) Coarsen() Point {
+	return Point{
+		Latitude:  math.Round(p.Latitude*coarseScale) / coarseScale,
+		Longitude: math.Round(p.Longitude*coarseScale) / coarseScale,
+	}
+}
+
+// Distance returns the great-circle distance between two points in
+// kilometres, using the haversine formula
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLng := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is a latitude/longitude range
+type Box struct {
+	MinLatitude  float64
+	MaxLatitude  float64
+	MinLongitude float64
+	MaxLongitude float64
+}
+
+// BoundingBox returns a box containing every point within radiusKm of
+// center, for narrowing down candidates with an index before computing exact
+// distances. Boxes that would reach over a pole or across the antimeridian
+// span every longitude instead.
+func BoundingBox(center Point, radiusKm float64) Box {
+	angular := radiusKm / EarthRadiusKm
+	lat := radians(center.Latitude)
+
+	box := Box{
+		MinLatitude:  degrees(lat - angular),
+		MaxLatitude:  degrees(lat + angular),
+		MinLongitude: -180,
+		MaxLongitude: 180,
+	}
+	if box.MinLatitude <= -90 || box.MaxLatitude >= 90 {
+		box.MinLatitude = math.Max(box.MinLatitude, -90)
+		box.MaxLatitude = math.Min(box.MaxLatitude, 90)
+		return box
+	}
+
+	dLng := degrees(math.Asin(math.Sin(angular) / math.Cos(lat)))
+	if center.Longitude-dLng >= -180 && center.Longitude+dLng <= 180 {
+		box.MinLongitude = center.Longitude - dLng
+		box.MaxLongitude = center.Longitude + dLng
+	}
+	return box
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
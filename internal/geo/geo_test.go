@@ -0,0 +1,126 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	paris := Point{Latitude: 48.8566, Longitude: 2.3522}
+	london := Point{Latitude: 51.5074, Longitude: -0.1278}
+	sydney := Point{Latitude: -33.8688, Longitude: 151.2093}
+
+	tests := []struct {
+		name string
+		a, b Point
+		want float64
+	}{
+		{"same point", paris, paris, 0},
+		{"paris to london", paris, london, 343.6},
+		{"london to sydney", london, sydney, 16993.9},
+		{"across the antimeridian", Point{0, 179.5}, Point{0, -179.5}, 111.2},
+		{"antipodes", Point{0, 0}, Point{0, 180}, math.Pi * EarthRadiusKm},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.a, tt.b)
+			if math.Abs(got-tt.want) > 1 {
+				t.Errorf("Distance = %.1f km, want %.1f km", got, tt.want)
+			}
+			if reverse := Distance(tt.b, tt.a); math.Abs(reverse-got) > 1e-9 {
+				t.Errorf("Distance is not symmetric: %f and %f", got, reverse)
+			}
+		})
+	}
+}
+
+// destination returns the point distanceKm away from origin along bearing,
+// in degrees clockwise from north
+func destination(origin Point, bearing, distanceKm float64) Point {
+	angular := distanceKm / EarthRadiusKm
+	lat1, lng1, b := radians(origin.Latitude), radians(origin.Longitude), radians(bearing)
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(angular) + math.Cos(lat1)*math.Sin(angular)*math.Cos(b))
+	lng2 := lng1 + math.Atan2(math.Sin(b)*math.Sin(angular)*math.Cos(lat1), math.Cos(angular)-math.Sin(lat1)*math.Sin(lat2))
+
+	lng := math.Mod(degrees(lng2)+540, 360) - 180
+	return Point{Latitude: degrees(lat2), Longitude: lng}
+}
+
+func (b Box) contains(p Point) bool {
+	const epsilon = 1e-9
+	return p.Latitude >= b.MinLatitude-epsilon && p.Latitude <= b.MaxLatitude+epsilon &&
+		p.Longitude >= b.MinLongitude-epsilon && p.Longitude <= b.MaxLongitude+epsilon
+}
+
+func TestBoundingBoxContainsCircle(t *testing.T) {
+	centers := []Point{
+		{48.8566, 2.3522},
+		{-33.8688, 151.2093},
+		{0, 0},
+		{64.1466, -21.9426},
+		{-54.8019, -68.3030},
+	}
+
+	for _, center := range centers {
+		for _, radius := range []float64{1, 50, 500} {
+			box := BoundingBox(center, radius)
+			for bearing := 0.0; bearing < 360; bearing += 5 {
+				for _, fraction := range []float64{0.5, 1} {
+					p := destination(center, bearing, radius*fraction)
+					if !box.contains(p) {
+						t.Errorf("BoundingBox(%v, %v) = %+v doesn't contain %+v at bearing %v", center, radius, box, p, bearing)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBoundingBoxIsTight(t *testing.T) {
+	center := Point{Latitude: 45, Longitude: 10}
+	box := BoundingBox(center, 100)
+
+	// Points just past the radius due north, south, east and west fall outside
+	for _, bearing := range []float64{0, 90, 180, 270} {
+		p := destination(center, bearing, 101)
+		if box.contains(p) {
+			t.Errorf("box %+v contains %+v at bearing %v, 101 km away", box, p, bearing)
+		}
+	}
+}
+
+func TestBoundingBoxEdgeCases(t *testing.T) {
+	tests := []struct {
+		name         string
+		center       Point
+		radiusKm     float64
+		allLongitude bool
+		maxLatitude  float64
+		minLatitude  float64
+	}{
+		{"across the antimeridian", Point{10, 179.9}, 50, true, 0, 0},
+		{"across the antimeridian westwards", Point{-10, -179.9}, 50, true, 0, 0},
+		{"over the north pole", Point{89.5, 0}, 100, true, 90, 0},
+		{"over the south pole", Point{-89.5, 45}, 100, true, 0, -90},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			box := BoundingBox(tt.center, tt.radiusKm)
+			if tt.allLongitude && (box.MinLongitude != -180 || box.MaxLongitude != 180) {
+				t.Errorf("box %+v should span every longitude", box)
+			}
+			if tt.maxLatitude != 0 && box.MaxLatitude != tt.maxLatitude {
+				t.Errorf("MaxLatitude = %v, want %v", box.MaxLatitude, tt.maxLatitude)
+			}
+			if tt.minLatitude != 0 && box.MinLatitude != tt.minLatitude {
+				t.Errorf("MinLatitude = %v, want %v", box.MinLatitude, tt.minLatitude)
+			}
+			if box.MinLatitude < -90 || box.MaxLatitude > 90 {
+				t.Errorf("box %+v reaches past a pole", box)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/generated"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/social"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+// Directives implements the schema directives declared in schema.graphqls
+func Directives(cfg *config.Config, users *user.Service, relations *social.Service) generated.DirectiveRoot {
+	return generated.DirectiveRoot{
+		Auth:       authDirective,
+		HasRole:    hasRoleDirective,
+		StepUp:     stepUpDirective(cfg.RecentAuthMaxAge),
+		Visibility: visibilityDirective(users, relations),
+	}
+}
+
+// authDirective rejects unauthenticated requests and personal access tokens
+// without the field's scope. Fields without a scope need an interactive session.
+func authDirective(ctx context.Context, obj interface{}, next graphql.Resolver, scope *models.Scope) (interface{}, error) {
+	var required auth.Scope
+	if scope != nil {
+		required = scopeFromModel(*scope)
+	}
+
+	if _, err := auth.RequireScope(ctx, required); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+// scopeFromModel converts a GraphQL scope to the scope personal access
+// tokens hold, e.g. READ_PROFILE to read:profile
+func scopeFromModel(scope models.Scope) auth.Scope {
+	return auth.Scope(strings.ToLower(strings.Replace(string(scope), "_", ":", 1)))
+}
+
+// hasRoleDirective rejects callers that don't hold at least the given role.
+// Role-gated fields are never available to personal access tokens.
+func hasRoleDirective(ctx context.Context, obj interface{}, next graphql.Resolver, role models.Role) (interface{}, error) {
+	if _, err := auth.RequireScope(ctx, ""); err != nil {
+		return nil, err
+	}
+	if _, err := auth.RequireRole(ctx, user.RoleFromModel(role)); err != nil {
+		return nil, err
+	}
+	return next(ctx)
+}
+
+// stepUpDirective requires a session authenticated at the given assurance
+// level and/or within maxAgeMinutes. Without arguments the session must have
+// been authenticated within the configured recent-auth window.
+func stepUpDirective(defaultMaxAge time.Duration) func(ctx context.Context, obj interface{}, next graphql.Resolver, aal *models.AuthenticatorAssuranceLevel, maxAgeMinutes *int) (interface{}, error) {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, aal *models.AuthenticatorAssuranceLevel, maxAgeMinutes *int) (interface{}, error) {
+		var req auth.StepUp
+		if aal != nil {
+			req.AAL = kratosclient.AuthenticatorAssuranceLevel(strings.ToLower(string(*aal)))
+		}
+		if maxAgeMinutes != nil {
+			req.MaxAge = time.Duration(*maxAgeMinutes) * time.Minute
+		}
+		if aal == nil && maxAgeMinutes == nil {
+			req.MaxAge = defaultMaxAge
+		}
+
+		if _, err := auth.RequireStepUp(ctx, req); err != nil {
+			return nil, err
+		}
+		return next(ctx)
+	}
+}
+
+// visibilityDirective resolves a profile field to null unless the owner's
+// privacy settings let the caller see it. Owners always see their own fields
+// and FOLLOWERS fields are also shown to the owner's followers.
+func visibilityDirective(users *user.Service, relations *social.Service) func(ctx context.Context, obj interface{}, next graphql.Resolver, field models.ProfileField) (interface{}, error) {
+	return func(ctx context.Context, obj interface{}, next graphql.Resolver, field models.ProfileField) (interface{}, error) {
+		ownerID, ok := profileOwner(obj)
+		if !ok {
+			return nil, fmt.Errorf("@visibility is not supported on %T", obj)
+		}
+
+		viewerID, _ := auth.GetUserIDFromContext(ctx)
+		if viewerID == ownerID {
+			return next(ctx)
+		}
+
+		settings, err := privacySettings(ctx, users, ownerID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch user.FieldVisibility(settings, field) {
+		case models.VisibilityPublic:
+			return next(ctx)
+		case models.VisibilityFollowers:
+			followed, err := isFollower(ctx, relations, viewerID, ownerID)
+			if err != nil {
+				return nil, err
+			}
+			if followed {
+				return next(ctx)
+			}
+		}
+		return nil, nil
+	}
+}
@@ -0,0 +1,24 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+func TestScopeFromModel(t *testing.T) {
+	if got := scopeFromModel(models.ScopeReadProfile); got != auth.ScopeReadProfile {
+		t.Errorf("scopeFromModel(READ_PROFILE) = %q, want %q", got, auth.ScopeReadProfile)
+	}
+
+	// Every scope a field can require must be one a token can be granted
+	for _, scope := range models.AllScope {
+		if got := scopeFromModel(scope); !got.Valid() {
+			t.Errorf("scopeFromModel(%s) = %q, which is not a valid token scope", scope, got)
+		}
+	}
+	if len(models.AllScope) != len(auth.Scopes) {
+		t.Errorf("%d GraphQL scopes for %d token scopes", len(models.AllScope), len(auth.Scopes))
+	}
+}
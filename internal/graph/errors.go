@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorPresenter exposes the code and details of auth errors in the GraphQL
+// error extensions so clients can tell UNAUTHENTICATED, FORBIDDEN and
+// STEP_UP_REQUIRED apart
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var authErr *auth.Error
+	if errors.As(err, &authErr) {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = make(map[string]interface{})
+		}
+		gqlErr.Extensions["code"] = authErr.Code
+		for key, value := range authErr.Details {
+			gqlErr.Extensions[key] = value
+		}
+	}
+
+	return gqlErr
+}
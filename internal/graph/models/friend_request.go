@@ -0,0 +1,12 @@
+package models
+
+// FriendRequest is bound in gqlgen.yaml instead of generated so that it can
+// hold the IDs of the users involved, which are resolved on demand
+type FriendRequest struct {
+	ID          string              `json:"id"`
+	SenderID    string              `json:"-"`
+	RecipientID string              `json:"-"`
+	Status      FriendRequestStatus `json:"status"`
+	CreatedAt   string              `json:"createdAt"`
+	ResolvedAt  *string             `json:"resolvedAt,omitempty"`
+}
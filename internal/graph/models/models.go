@@ -2,18 +2,239 @@
 
 package models
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type AccountDeletion struct {
+	ScheduledFor string `json:"scheduledFor"`
+}
+
+type ArrayFilter struct {
+	AnyOf []string `json:"anyOf,omitempty"`
+	AllOf []string `json:"allOf,omitempty"`
+}
+
 type AuthResponse struct {
 	Success bool    `json:"success"`
 	Message *string `json:"message,omitempty"`
 	User    *User   `json:"user,omitempty"`
 }
 
+type City struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type CreatePersonalAccessTokenInput struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays *int     `json:"expiresInDays,omitempty"`
+}
+
+type CreatePersonalAccessTokenPayload struct {
+	Token               string               `json:"token"`
+	PersonalAccessToken *PersonalAccessToken `json:"personalAccessToken"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type FriendRequestConnection struct {
+	Edges    []*FriendRequestEdge `json:"edges"`
+	PageInfo *PageInfo            `json:"pageInfo"`
+}
+
+type FriendRequestEdge struct {
+	Node   *FriendRequest `json:"node"`
+	Cursor string         `json:"cursor"`
+}
+
+type HandleAvailability struct {
+	Handle    string                   `json:"handle"`
+	Available bool                     `json:"available"`
+	Reason    *HandleUnavailableReason `json:"reason,omitempty"`
+}
+
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	UpdatedAt string  `json:"updatedAt"`
+}
+
+type LocationInput struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
 type Mutation struct {
 }
 
+type NearbyTravelerConnection struct {
+	Edges    []*NearbyTravelerEdge `json:"edges"`
+	PageInfo *PageInfo             `json:"pageInfo"`
+}
+
+type NearbyTravelerEdge struct {
+	Node       *User   `json:"node"`
+	Cursor     string  `json:"cursor"`
+	DistanceKm float64 `json:"distanceKm"`
+}
+
+type PageInfo struct {
+	HasNextPage     bool    `json:"hasNextPage"`
+	HasPreviousPage bool    `json:"hasPreviousPage"`
+	StartCursor     *string `json:"startCursor,omitempty"`
+	EndCursor       *string `json:"endCursor,omitempty"`
+}
+
+type PersonalAccessToken struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  *string  `json:"expiresAt,omitempty"`
+	LastUsedAt *string  `json:"lastUsedAt,omitempty"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type PersonalAccessTokenConnection struct {
+	Edges    []*PersonalAccessTokenEdge `json:"edges"`
+	PageInfo *PageInfo                  `json:"pageInfo"`
+}
+
+type PersonalAccessTokenEdge struct {
+	Node   *PersonalAccessToken `json:"node"`
+	Cursor string               `json:"cursor"`
+}
+
+type PrivacySettings struct {
+	Email             Visibility `json:"email"`
+	LastName          Visibility `json:"lastName"`
+	Bio               Visibility `json:"bio"`
+	Interests         Visibility `json:"interests"`
+	TravelPreferences Visibility `json:"travelPreferences"`
+	UpdatedAt         *string    `json:"updatedAt,omitempty"`
+}
+
+type ProfileSnapshot struct {
+	Handle             *string            `json:"handle,omitempty"`
+	FirstName          *string            `json:"firstName,omitempty"`
+	LastName           *string            `json:"lastName,omitempty"`
+	Bio                *string            `json:"bio,omitempty"`
+	Interests          []string           `json:"interests,omitempty"`
+	ProfilePicture     *string            `json:"profilePicture,omitempty"`
+	HasUploadedPicture bool               `json:"hasUploadedPicture"`
+	TravelPreferences  *TravelPreferences `json:"travelPreferences,omitempty"`
+	CapturedAt         string             `json:"capturedAt"`
+}
+
 type Query struct {
 }
 
+type Report struct {
+	ID         string  `json:"id"`
+	ReporterID *string `json:"reporterId,omitempty"`
+	// Null once the reported account has been deleted
+	ReportedUserID *string           `json:"reportedUserId,omitempty"`
+	Category       ReportCategory    `json:"category"`
+	Details        *string           `json:"details,omitempty"`
+	Snapshot       *ProfileSnapshot  `json:"snapshot"`
+	Status         ReportStatus      `json:"status"`
+	AssignedTo     *string           `json:"assignedTo,omitempty"`
+	Action         *ModerationAction `json:"action,omitempty"`
+	ActionNote     *string           `json:"actionNote,omitempty"`
+	SuspensionID   *string           `json:"suspensionId,omitempty"`
+	ResolvedBy     *string           `json:"resolvedBy,omitempty"`
+	ResolvedAt     *string           `json:"resolvedAt,omitempty"`
+	CreatedAt      string            `json:"createdAt"`
+}
+
+type ReportConnection struct {
+	Edges    []*ReportEdge `json:"edges"`
+	PageInfo *PageInfo     `json:"pageInfo"`
+}
+
+type ReportEdge struct {
+	Node   *Report `json:"node"`
+	Cursor string  `json:"cursor"`
+}
+
+type ReportUserInput struct {
+	UserID   string         `json:"userId"`
+	Category ReportCategory `json:"category"`
+	Details  *string        `json:"details,omitempty"`
+}
+
+type ResolveReportInput struct {
+	ReportID      string           `json:"reportId"`
+	Action        ModerationAction `json:"action"`
+	Note          *string          `json:"note,omitempty"`
+	DurationHours *int             `json:"durationHours,omitempty"`
+}
+
+type Session struct {
+	ID              string           `json:"id"`
+	Current         bool             `json:"current"`
+	AuthenticatedAt *string          `json:"authenticatedAt,omitempty"`
+	IssuedAt        *string          `json:"issuedAt,omitempty"`
+	ExpiresAt       *string          `json:"expiresAt,omitempty"`
+	Aal             *string          `json:"aal,omitempty"`
+	Devices         []*SessionDevice `json:"devices"`
+}
+
+type SessionConnection struct {
+	Edges    []*SessionEdge `json:"edges"`
+	PageInfo *PageInfo      `json:"pageInfo"`
+}
+
+type SessionDevice struct {
+	ID        string  `json:"id"`
+	IPAddress *string `json:"ipAddress,omitempty"`
+	UserAgent *string `json:"userAgent,omitempty"`
+	Location  *string `json:"location,omitempty"`
+}
+
+type SessionEdge struct {
+	Node   *Session `json:"node"`
+	Cursor string   `json:"cursor"`
+}
+
+type SuspendUserInput struct {
+	UserID        string `json:"userId"`
+	Reason        string `json:"reason"`
+	DurationHours *int   `json:"durationHours,omitempty"`
+}
+
+type Suspension struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"userId"`
+	Reason    string  `json:"reason"`
+	IssuedBy  *string `json:"issuedBy,omitempty"`
+	ExpiresAt *string `json:"expiresAt,omitempty"`
+	CreatedAt string  `json:"createdAt"`
+	LiftedAt  *string `json:"liftedAt,omitempty"`
+	LiftedBy  *string `json:"liftedBy,omitempty"`
+	Active    bool    `json:"active"`
+}
+
+type SuspensionConnection struct {
+	Edges    []*SuspensionEdge `json:"edges"`
+	PageInfo *PageInfo         `json:"pageInfo"`
+}
+
+type SuspensionEdge struct {
+	Node   *Suspension `json:"node"`
+	Cursor string      `json:"cursor"`
+}
+
 type TravelPreferences struct {
 	ID                  string   `json:"id"`
 	UserID              string   `json:"userId"`
@@ -23,6 +244,45 @@ type TravelPreferences struct {
 	UpdatedAt           string   `json:"updatedAt"`
 }
 
+type TravelerFacets struct {
+	TravelStyles []*FacetCount `json:"travelStyles"`
+	Languages    []*FacetCount `json:"languages"`
+	Activities   []*FacetCount `json:"activities"`
+	Interests    []*FacetCount `json:"interests"`
+}
+
+type TravelerFilter struct {
+	TravelStyles []string     `json:"travelStyles,omitempty"`
+	Languages    *ArrayFilter `json:"languages,omitempty"`
+	Activities   *ArrayFilter `json:"activities,omitempty"`
+	Interests    *ArrayFilter `json:"interests,omitempty"`
+}
+
+type TravelerSearchResult struct {
+	Travelers  *UserConnection `json:"travelers"`
+	TotalCount int             `json:"totalCount"`
+	Facets     *TravelerFacets `json:"facets"`
+}
+
+type TravelerSuggestion struct {
+	User                  *User              `json:"user"`
+	Reasons               []SuggestionReason `json:"reasons"`
+	MutualConnectionCount int                `json:"mutualConnectionCount"`
+	SharedInterests       []string           `json:"sharedInterests"`
+	SharedActivities      []string           `json:"sharedActivities"`
+	SharedLanguages       []string           `json:"sharedLanguages"`
+	SharedTravelStyle     *string            `json:"sharedTravelStyle,omitempty"`
+	DistanceKm            *float64           `json:"distanceKm,omitempty"`
+}
+
+type UpdatePrivacySettingsInput struct {
+	Email             *Visibility `json:"email,omitempty"`
+	LastName          *Visibility `json:"lastName,omitempty"`
+	Bio               *Visibility `json:"bio,omitempty"`
+	Interests         *Visibility `json:"interests,omitempty"`
+	TravelPreferences *Visibility `json:"travelPreferences,omitempty"`
+}
+
 type UpdateProfileInput struct {
 	FirstName      *string  `json:"firstName,omitempty"`
 	LastName       *string  `json:"lastName,omitempty"`
@@ -37,19 +297,598 @@ type UpdateTravelPreferencesInput struct {
 	LanguagesSpoken     []string `json:"languagesSpoken,omitempty"`
 }
 
-type User struct {
-	ID             string   `json:"id"`
-	Email          string   `json:"email"`
-	FirstName      *string  `json:"firstName,omitempty"`
-	LastName       *string  `json:"lastName,omitempty"`
-	ProfilePicture *string  `json:"profilePicture,omitempty"`
-	Bio            *string  `json:"bio,omitempty"`
-	Interests      []string `json:"interests,omitempty"`
-	CreatedAt      string   `json:"createdAt"`
-	UpdatedAt      string   `json:"updatedAt"`
+type UserConnection struct {
+	Edges    []*UserEdge `json:"edges"`
+	PageInfo *PageInfo   `json:"pageInfo"`
+}
+
+type UserEdge struct {
+	Node   *User  `json:"node"`
+	Cursor string `json:"cursor"`
 }
 
 type UserProfile struct {
 	User              *User              `json:"user"`
 	TravelPreferences *TravelPreferences `json:"travelPreferences,omitempty"`
 }
+
+type AuthenticatorAssuranceLevel string
+
+const (
+	AuthenticatorAssuranceLevelAal1 AuthenticatorAssuranceLevel = "AAL1"
+	AuthenticatorAssuranceLevelAal2 AuthenticatorAssuranceLevel = "AAL2"
+)
+
+var AllAuthenticatorAssuranceLevel = []AuthenticatorAssuranceLevel{
+	AuthenticatorAssuranceLevelAal1,
+	AuthenticatorAssuranceLevelAal2,
+}
+
+func (e AuthenticatorAssuranceLevel) IsValid() bool {
+	switch e {
+	case AuthenticatorAssuranceLevelAal1, AuthenticatorAssuranceLevelAal2:
+		return true
+	}
+	return false
+}
+
+func (e AuthenticatorAssuranceLevel) String() string {
+	return string(e)
+}
+
+func (e *AuthenticatorAssuranceLevel) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuthenticatorAssuranceLevel(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuthenticatorAssuranceLevel", str)
+	}
+	return nil
+}
+
+func (e AuthenticatorAssuranceLevel) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type FriendRequestResponse string
+
+const (
+	FriendRequestResponseAccept  FriendRequestResponse = "ACCEPT"
+	FriendRequestResponseDecline FriendRequestResponse = "DECLINE"
+)
+
+var AllFriendRequestResponse = []FriendRequestResponse{
+	FriendRequestResponseAccept,
+	FriendRequestResponseDecline,
+}
+
+func (e FriendRequestResponse) IsValid() bool {
+	switch e {
+	case FriendRequestResponseAccept, FriendRequestResponseDecline:
+		return true
+	}
+	return false
+}
+
+func (e FriendRequestResponse) String() string {
+	return string(e)
+}
+
+func (e *FriendRequestResponse) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FriendRequestResponse(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FriendRequestResponse", str)
+	}
+	return nil
+}
+
+func (e FriendRequestResponse) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type FriendRequestStatus string
+
+const (
+	FriendRequestStatusPending   FriendRequestStatus = "PENDING"
+	FriendRequestStatusAccepted  FriendRequestStatus = "ACCEPTED"
+	FriendRequestStatusDeclined  FriendRequestStatus = "DECLINED"
+	FriendRequestStatusCancelled FriendRequestStatus = "CANCELLED"
+)
+
+var AllFriendRequestStatus = []FriendRequestStatus{
+	FriendRequestStatusPending,
+	FriendRequestStatusAccepted,
+	FriendRequestStatusDeclined,
+	FriendRequestStatusCancelled,
+}
+
+func (e FriendRequestStatus) IsValid() bool {
+	switch e {
+	case FriendRequestStatusPending, FriendRequestStatusAccepted, FriendRequestStatusDeclined, FriendRequestStatusCancelled:
+		return true
+	}
+	return false
+}
+
+func (e FriendRequestStatus) String() string {
+	return string(e)
+}
+
+func (e *FriendRequestStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = FriendRequestStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid FriendRequestStatus", str)
+	}
+	return nil
+}
+
+func (e FriendRequestStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type HandleUnavailableReason string
+
+const (
+	HandleUnavailableReasonInvalid  HandleUnavailableReason = "INVALID"
+	HandleUnavailableReasonReserved HandleUnavailableReason = "RESERVED"
+	HandleUnavailableReasonTaken    HandleUnavailableReason = "TAKEN"
+)
+
+var AllHandleUnavailableReason = []HandleUnavailableReason{
+	HandleUnavailableReasonInvalid,
+	HandleUnavailableReasonReserved,
+	HandleUnavailableReasonTaken,
+}
+
+func (e HandleUnavailableReason) IsValid() bool {
+	switch e {
+	case HandleUnavailableReasonInvalid, HandleUnavailableReasonReserved, HandleUnavailableReasonTaken:
+		return true
+	}
+	return false
+}
+
+func (e HandleUnavailableReason) String() string {
+	return string(e)
+}
+
+func (e *HandleUnavailableReason) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = HandleUnavailableReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid HandleUnavailableReason", str)
+	}
+	return nil
+}
+
+func (e HandleUnavailableReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ModerationAction string
+
+const (
+	ModerationActionDismiss       ModerationAction = "DISMISS"
+	ModerationActionWarn          ModerationAction = "WARN"
+	ModerationActionSuspend       ModerationAction = "SUSPEND"
+	ModerationActionRemoveContent ModerationAction = "REMOVE_CONTENT"
+)
+
+var AllModerationAction = []ModerationAction{
+	ModerationActionDismiss,
+	ModerationActionWarn,
+	ModerationActionSuspend,
+	ModerationActionRemoveContent,
+}
+
+func (e ModerationAction) IsValid() bool {
+	switch e {
+	case ModerationActionDismiss, ModerationActionWarn, ModerationActionSuspend, ModerationActionRemoveContent:
+		return true
+	}
+	return false
+}
+
+func (e ModerationAction) String() string {
+	return string(e)
+}
+
+func (e *ModerationAction) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ModerationAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ModerationAction", str)
+	}
+	return nil
+}
+
+func (e ModerationAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ProfileField string
+
+const (
+	ProfileFieldEmail             ProfileField = "EMAIL"
+	ProfileFieldLastName          ProfileField = "LAST_NAME"
+	ProfileFieldBio               ProfileField = "BIO"
+	ProfileFieldInterests         ProfileField = "INTERESTS"
+	ProfileFieldTravelPreferences ProfileField = "TRAVEL_PREFERENCES"
+)
+
+var AllProfileField = []ProfileField{
+	ProfileFieldEmail,
+	ProfileFieldLastName,
+	ProfileFieldBio,
+	ProfileFieldInterests,
+	ProfileFieldTravelPreferences,
+}
+
+func (e ProfileField) IsValid() bool {
+	switch e {
+	case ProfileFieldEmail, ProfileFieldLastName, ProfileFieldBio, ProfileFieldInterests, ProfileFieldTravelPreferences:
+		return true
+	}
+	return false
+}
+
+func (e ProfileField) String() string {
+	return string(e)
+}
+
+func (e *ProfileField) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProfileField(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProfileField", str)
+	}
+	return nil
+}
+
+func (e ProfileField) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ProfilePictureSize string
+
+const (
+	ProfilePictureSizeSmall  ProfilePictureSize = "SMALL"
+	ProfilePictureSizeMedium ProfilePictureSize = "MEDIUM"
+	ProfilePictureSizeLarge  ProfilePictureSize = "LARGE"
+)
+
+var AllProfilePictureSize = []ProfilePictureSize{
+	ProfilePictureSizeSmall,
+	ProfilePictureSizeMedium,
+	ProfilePictureSizeLarge,
+}
+
+func (e ProfilePictureSize) IsValid() bool {
+	switch e {
+	case ProfilePictureSizeSmall, ProfilePictureSizeMedium, ProfilePictureSizeLarge:
+		return true
+	}
+	return false
+}
+
+func (e ProfilePictureSize) String() string {
+	return string(e)
+}
+
+func (e *ProfilePictureSize) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProfilePictureSize(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProfilePictureSize", str)
+	}
+	return nil
+}
+
+func (e ProfilePictureSize) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportCategory string
+
+const (
+	ReportCategorySpam                 ReportCategory = "SPAM"
+	ReportCategoryHarassment           ReportCategory = "HARASSMENT"
+	ReportCategoryHateSpeech           ReportCategory = "HATE_SPEECH"
+	ReportCategoryInappropriateContent ReportCategory = "INAPPROPRIATE_CONTENT"
+	ReportCategoryImpersonation        ReportCategory = "IMPERSONATION"
+	ReportCategoryScam                 ReportCategory = "SCAM"
+	ReportCategoryOther                ReportCategory = "OTHER"
+)
+
+var AllReportCategory = []ReportCategory{
+	ReportCategorySpam,
+	ReportCategoryHarassment,
+	ReportCategoryHateSpeech,
+	ReportCategoryInappropriateContent,
+	ReportCategoryImpersonation,
+	ReportCategoryScam,
+	ReportCategoryOther,
+}
+
+func (e ReportCategory) IsValid() bool {
+	switch e {
+	case ReportCategorySpam, ReportCategoryHarassment, ReportCategoryHateSpeech, ReportCategoryInappropriateContent, ReportCategoryImpersonation, ReportCategoryScam, ReportCategoryOther:
+		return true
+	}
+	return false
+}
+
+func (e ReportCategory) String() string {
+	return string(e)
+}
+
+func (e *ReportCategory) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportCategory(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportCategory", str)
+	}
+	return nil
+}
+
+func (e ReportCategory) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "OPEN"
+	ReportStatusInReview  ReportStatus = "IN_REVIEW"
+	ReportStatusActioned  ReportStatus = "ACTIONED"
+	ReportStatusDismissed ReportStatus = "DISMISSED"
+)
+
+var AllReportStatus = []ReportStatus{
+	ReportStatusOpen,
+	ReportStatusInReview,
+	ReportStatusActioned,
+	ReportStatusDismissed,
+}
+
+func (e ReportStatus) IsValid() bool {
+	switch e {
+	case ReportStatusOpen, ReportStatusInReview, ReportStatusActioned, ReportStatusDismissed:
+		return true
+	}
+	return false
+}
+
+func (e ReportStatus) String() string {
+	return string(e)
+}
+
+func (e *ReportStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReportStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReportStatus", str)
+	}
+	return nil
+}
+
+func (e ReportStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Role string
+
+const (
+	RoleUser      Role = "USER"
+	RoleModerator Role = "MODERATOR"
+	RoleAdmin     Role = "ADMIN"
+)
+
+var AllRole = []Role{
+	RoleUser,
+	RoleModerator,
+	RoleAdmin,
+}
+
+func (e Role) IsValid() bool {
+	switch e {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e Role) String() string {
+	return string(e)
+}
+
+func (e *Role) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Role(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Role", str)
+	}
+	return nil
+}
+
+func (e Role) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+// Scopes a personal access token can be granted. Token scopes are written in
+// lower case with a colon, e.g. READ_PROFILE is read:profile.
+type Scope string
+
+const (
+	ScopeReadProfile  Scope = "READ_PROFILE"
+	ScopeWriteProfile Scope = "WRITE_PROFILE"
+	ScopeReadUsers    Scope = "READ_USERS"
+)
+
+var AllScope = []Scope{
+	ScopeReadProfile,
+	ScopeWriteProfile,
+	ScopeReadUsers,
+}
+
+func (e Scope) IsValid() bool {
+	switch e {
+	case ScopeReadProfile, ScopeWriteProfile, ScopeReadUsers:
+		return true
+	}
+	return false
+}
+
+func (e Scope) String() string {
+	return string(e)
+}
+
+func (e *Scope) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Scope(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Scope", str)
+	}
+	return nil
+}
+
+func (e Scope) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SuggestionReason string
+
+const (
+	SuggestionReasonMutualConnections SuggestionReason = "MUTUAL_CONNECTIONS"
+	SuggestionReasonNearby            SuggestionReason = "NEARBY"
+	SuggestionReasonSharedInterests   SuggestionReason = "SHARED_INTERESTS"
+	SuggestionReasonSharedTravelStyle SuggestionReason = "SHARED_TRAVEL_STYLE"
+	SuggestionReasonSharedActivities  SuggestionReason = "SHARED_ACTIVITIES"
+	SuggestionReasonSharedLanguages   SuggestionReason = "SHARED_LANGUAGES"
+)
+
+var AllSuggestionReason = []SuggestionReason{
+	SuggestionReasonMutualConnections,
+	SuggestionReasonNearby,
+	SuggestionReasonSharedInterests,
+	SuggestionReasonSharedTravelStyle,
+	SuggestionReasonSharedActivities,
+	SuggestionReasonSharedLanguages,
+}
+
+func (e SuggestionReason) IsValid() bool {
+	switch e {
+	case SuggestionReasonMutualConnections, SuggestionReasonNearby, SuggestionReasonSharedInterests, SuggestionReasonSharedTravelStyle, SuggestionReasonSharedActivities, SuggestionReasonSharedLanguages:
+		return true
+	}
+	return false
+}
+
+func (e SuggestionReason) String() string {
+	return string(e)
+}
+
+func (e *SuggestionReason) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SuggestionReason(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SuggestionReason", str)
+	}
+	return nil
+}
+
+func (e SuggestionReason) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Visibility string
+
+const (
+	VisibilityPublic    Visibility = "PUBLIC"
+	VisibilityFollowers Visibility = "FOLLOWERS"
+	VisibilityOnlyMe    Visibility = "ONLY_ME"
+)
+
+var AllVisibility = []Visibility{
+	VisibilityPublic,
+	VisibilityFollowers,
+	VisibilityOnlyMe,
+}
+
+func (e Visibility) IsValid() bool {
+	switch e {
+	case VisibilityPublic, VisibilityFollowers, VisibilityOnlyMe:
+		return true
+	}
+	return false
+}
+
+func (e Visibility) String() string {
+	return string(e)
+}
+
+func (e *Visibility) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Visibility(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Visibility", str)
+	}
+	return nil
+}
+
+func (e Visibility) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
@@ -0,0 +1,24 @@
+package models
+
+// User is bound in gqlgen.yaml instead of generated so that it can carry
+// columns the schema doesn't expose
+type User struct {
+	ID             string   `json:"id"`
+	Handle         *string  `json:"handle,omitempty"`
+	Email          *string  `json:"email,omitempty"`
+	FirstName      *string  `json:"firstName,omitempty"`
+	LastName       *string  `json:"lastName,omitempty"`
+	ProfilePicture *string  `json:"profilePicture,omitempty"`
+	Bio            *string  `json:"bio,omitempty"`
+	Interests      []string `json:"interests,omitempty"`
+	Role           Role     `json:"role"`
+	CreatedAt      string   `json:"createdAt"`
+	UpdatedAt      string   `json:"updatedAt"`
+
+	// ProfilePictureKey is the BlobStore key of an uploaded profile picture,
+	// which takes precedence over a ProfilePicture URL
+	ProfilePictureKey *string `json:"-"`
+
+	// HomeCityID is the gazetteer ID of the user's home city
+	HomeCityID *string `json:"-"`
+}
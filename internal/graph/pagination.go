@@ -0,0 +1,83 @@
+package graph
+
+import (
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+)
+
+// pageInfo builds the Relay PageInfo of a page
+func pageInfo[T any](page *db.Page[T]) *models.PageInfo {
+	info := &models.PageInfo{
+		HasNextPage:     page.HasNextPage,
+		HasPreviousPage: page.HasPreviousPage,
+	}
+	if n := len(page.Cursors); n > 0 {
+		info.StartCursor = &page.Cursors[0]
+		info.EndCursor = &page.Cursors[n-1]
+	}
+	return info
+}
+
+// userConnection converts a page of users to a UserConnection
+func userConnection(page *db.Page[*models.User]) *models.UserConnection {
+	edges := make([]*models.UserEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.UserEdge{Node: item, Cursor: page.Cursors[i]}
+	}
+	return &models.UserConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
+
+// suspensionConnection converts a page of suspensions to a SuspensionConnection
+func suspensionConnection(page *db.Page[*models.Suspension]) *models.SuspensionConnection {
+	edges := make([]*models.SuspensionEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.SuspensionEdge{Node: item, Cursor: page.Cursors[i]}
+	}
+	return &models.SuspensionConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
+
+// nearbyTravelerConnection converts a page of nearby travelers to a NearbyTravelerConnection
+func nearbyTravelerConnection(page *db.Page[*user.NearbyTraveler]) *models.NearbyTravelerConnection {
+	edges := make([]*models.NearbyTravelerEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.NearbyTravelerEdge{Node: item.User, Cursor: page.Cursors[i], DistanceKm: item.DistanceKm}
+	}
+	return &models.NearbyTravelerConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
+
+// friendRequestConnection converts a page of friend requests to a FriendRequestConnection
+func friendRequestConnection(page *db.Page[*models.FriendRequest]) *models.FriendRequestConnection {
+	edges := make([]*models.FriendRequestEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.FriendRequestEdge{Node: item, Cursor: page.Cursors[i]}
+	}
+	return &models.FriendRequestConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
+
+// reportConnection converts a page of reports to a ReportConnection
+func reportConnection(page *db.Page[*models.Report]) *models.ReportConnection {
+	edges := make([]*models.ReportEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.ReportEdge{Node: item, Cursor: page.Cursors[i]}
+	}
+	return &models.ReportConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
+
+// personalAccessTokenConnection converts a page of tokens to a PersonalAccessTokenConnection
+func personalAccessTokenConnection(page *db.Page[*models.PersonalAccessToken]) *models.PersonalAccessTokenConnection {
+	edges := make([]*models.PersonalAccessTokenEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.PersonalAccessTokenEdge{Node: item, Cursor: page.Cursors[i]}
+	}
+	return &models.PersonalAccessTokenConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
+
+// sessionConnection converts a page of sessions to a SessionConnection
+func sessionConnection(page *db.Page[*models.Session]) *models.SessionConnection {
+	edges := make([]*models.SessionEdge, len(page.Items))
+	for i, item := range page.Items {
+		edges[i] = &models.SessionEdge{Node: item, Cursor: page.Cursors[i]}
+	}
+	return &models.SessionConnection{Edges: edges, PageInfo: pageInfo(page)}
+}
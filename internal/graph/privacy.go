@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/social"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+)
+
+type privacyCacheKey struct{}
+
+// privacyCache memoises privacy settings and whether the viewer follows each
+// user for the duration of one operation, so a list of users costs one lookup
+// per user rather than one per field
+type privacyCache struct {
+	mu       sync.Mutex
+	settings map[string]*models.PrivacySettings
+	followed map[string]bool
+}
+
+// PrivacyCache is an operation middleware that gives each operation its own
+// privacy settings cache
+func PrivacyCache(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	return next(context.WithValue(ctx, privacyCacheKey{}, &privacyCache{
+		settings: make(map[string]*models.PrivacySettings),
+		followed: make(map[string]bool),
+	}))
+}
+
+// privacySettings returns the privacy settings of a user, going through the
+// operation's cache when there is one
+func privacySettings(ctx context.Context, users *user.Service, userID string) (*models.PrivacySettings, error) {
+	cache, ok := ctx.Value(privacyCacheKey{}).(*privacyCache)
+	if !ok {
+		return users.GetPrivacySettings(ctx, userID)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if settings, ok := cache.settings[userID]; ok {
+		return settings, nil
+	}
+
+	settings, err := users.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cache.settings[userID] = settings
+	return settings, nil
+}
+
+// isFollower reports whether the viewer follows a user, going through the
+// operation's cache when there is one. The viewer is the same throughout an
+// operation, so the cache is keyed by the followed user alone.
+func isFollower(ctx context.Context, relations *social.Service, viewerID, userID string) (bool, error) {
+	cache, ok := ctx.Value(privacyCacheKey{}).(*privacyCache)
+	if !ok {
+		return relations.IsFollowing(ctx, viewerID, userID)
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if followed, ok := cache.followed[userID]; ok {
+		return followed, nil
+	}
+
+	followed, err := relations.IsFollowing(ctx, viewerID, userID)
+	if err != nil {
+		return false, err
+	}
+	cache.followed[userID] = followed
+	return followed, nil
+}
+
+// profileOwner returns the ID of the user a profile object belongs to
+func profileOwner(obj interface{}) (string, bool) {
+	switch profile := obj.(type) {
+	case *models.User:
+		return profile.ID, true
+	case *models.UserProfile:
+		return profile.User.ID, true
+	}
+	return "", false
+}
+
+// visibleUserOrNil returns a user as the viewer may see them, or nil when the
+// user is hidden from the viewer, for nullable fields that point at a user
+// who may since have been suspended, deleted or blocked
+func visibleUserOrNil(ctx context.Context, users *user.Service, viewerID, id string) (*models.User, error) {
+	found, err := users.GetVisibleUserByID(ctx, viewerID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return found, err
+}
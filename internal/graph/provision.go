@@ -0,0 +1,20 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// requireUser authenticates the caller and makes sure they have a local users
+// row, provisioning it from their Kratos identity if the registration webhook
+// hasn't done so yet.
+func (r *Resolver) requireUser(ctx context.Context) (*models.User, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.GetOrCreateUser(ctx, userID)
+}
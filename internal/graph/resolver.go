@@ -3,6 +3,12 @@ package graph
 import (
 	// "github.com/karthickgandhiTV/travel-social-backend/internal/graph/generated"
 	// "github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/moderation"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/social"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/storage"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/token"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
 )
 
@@ -12,5 +18,11 @@ import (
 
 // In your graph package
 type Resolver struct {
-	UserService *user.Service
+	Config            *config.Config
+	Authenticator     auth.Authenticator
+	UserService       *user.Service
+	TokenService      *token.Service
+	SocialService     *social.Service
+	ModerationService *moderation.Service
+	MediaSigner       *storage.URLSigner
 }
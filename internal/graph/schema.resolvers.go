@@ -6,69 +6,747 @@ package graph
 
 import (
 	"context"
+	"errors"
+	"log"
 
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/generated"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
 )
 
+// Sender returns the user who sent a friend request
+func (r *friendRequestResolver) Sender(ctx context.Context, obj *models.FriendRequest) (*models.User, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	return visibleUserOrNil(ctx, r.UserService, viewerID, obj.SenderID)
+}
+
+// Recipient returns the user a friend request was sent to
+func (r *friendRequestResolver) Recipient(ctx context.Context, obj *models.FriendRequest) (*models.User, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	return visibleUserOrNil(ctx, r.UserService, viewerID, obj.RecipientID)
+}
+
 // UpdateProfile updates the user's profile
 func (r *mutationResolver) UpdateProfile(ctx context.Context, input models.UpdateProfileInput) (*models.User, error) {
-	userID, err := auth.RequireAuth(ctx)
+	me, err := r.requireUser(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.UserService.UpdateProfile(ctx, userID, input)
+	return r.UserService.UpdateProfile(ctx, me.ID, input)
 }
 
 // UpdateTravelPreferences updates the user's travel preferences
 func (r *mutationResolver) UpdateTravelPreferences(ctx context.Context, input models.UpdateTravelPreferencesInput) (*models.TravelPreferences, error) {
-	userID, err := auth.RequireAuth(ctx)
+	me, err := r.requireUser(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.UserService.UpdateTravelPreferences(ctx, userID, input)
+	return r.UserService.UpdateTravelPreferences(ctx, me.ID, input)
 }
 
-// Me returns the currently authenticated user
-func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
+// SetHandle changes the current user's handle
+func (r *mutationResolver) SetHandle(ctx context.Context, handle string) (*models.User, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.SetHandle(ctx, me.ID, handle)
+}
+
+// UploadProfilePicture stores an uploaded image as the user's profile picture
+func (r *mutationResolver) UploadProfilePicture(ctx context.Context, file graphql.Upload) (*models.User, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.UploadProfilePicture(ctx, me.ID, file.File, file.Size)
+}
+
+// UpdatePrivacySettings changes who can see the current user's profile fields
+func (r *mutationResolver) UpdatePrivacySettings(ctx context.Context, input models.UpdatePrivacySettingsInput) (*models.PrivacySettings, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.UpdatePrivacySettings(ctx, me.ID, input)
+}
+
+// SetHomeCity sets or clears the current user's home city
+func (r *mutationResolver) SetHomeCity(ctx context.Context, cityID *string) (*models.User, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.SetHomeCity(ctx, me.ID, cityID)
+}
+
+// SetCurrentLocation starts or, given null, stops sharing the current user's location
+func (r *mutationResolver) SetCurrentLocation(ctx context.Context, location *models.LocationInput) (*models.User, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.SetCurrentLocation(ctx, me.ID, location)
+}
+
+// Follow makes the current user follow another user
+func (r *mutationResolver) Follow(ctx context.Context, userID string) (*models.User, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SocialService.Follow(ctx, me.ID, userID)
+}
+
+// Unfollow stops the current user following another user
+func (r *mutationResolver) Unfollow(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.Unfollow(ctx, me.ID, userID)
+}
+
+// SendFriendRequest asks another user to become the current user's friend
+func (r *mutationResolver) SendFriendRequest(ctx context.Context, userID string) (*models.FriendRequest, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SocialService.SendFriendRequest(ctx, me.ID, userID)
+}
+
+// RespondToFriendRequest accepts or declines a friend request sent to the current user
+func (r *mutationResolver) RespondToFriendRequest(ctx context.Context, requestID string, response models.FriendRequestResponse) (*models.FriendRequest, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SocialService.RespondToFriendRequest(ctx, me.ID, requestID, response)
+}
+
+// CancelFriendRequest withdraws a friend request the current user sent
+func (r *mutationResolver) CancelFriendRequest(ctx context.Context, requestID string) (*models.FriendRequest, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SocialService.CancelFriendRequest(ctx, me.ID, requestID)
+}
+
+// RemoveFriend ends a friendship of the current user
+func (r *mutationResolver) RemoveFriend(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.RemoveFriend(ctx, me.ID, userID)
+}
+
+// BlockUser blocks another user for the current user
+func (r *mutationResolver) BlockUser(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.BlockUser(ctx, me.ID, userID)
+}
+
+// UnblockUser lifts a block the current user placed
+func (r *mutationResolver) UnblockUser(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.UnblockUser(ctx, me.ID, userID)
+}
+
+// MuteUser hides another user from the current user's searches and suggestions
+func (r *mutationResolver) MuteUser(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.MuteUser(ctx, me.ID, userID)
+}
+
+// UnmuteUser lifts a mute the current user placed
+func (r *mutationResolver) UnmuteUser(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.UnmuteUser(ctx, me.ID, userID)
+}
+
+// DismissSuggestion stops a user from being suggested to the current user again
+func (r *mutationResolver) DismissSuggestion(ctx context.Context, userID string) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.SocialService.DismissSuggestion(ctx, me.ID, userID)
+}
+
+// SetUserRole changes the role of a user
+func (r *mutationResolver) SetUserRole(ctx context.Context, userID string, role models.Role) (*models.User, error) {
+	adminID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins can't demote themselves, so there is always someone left to manage roles
+	if userID == adminID && role != models.RoleAdmin {
+		return nil, &auth.Error{
+			Code:    auth.CodeForbidden,
+			Message: "admins cannot change their own role",
+		}
+	}
+
+	return r.UserService.SetUserRole(ctx, userID, role)
+}
+
+// CreatePersonalAccessToken issues a personal access token for the current user
+func (r *mutationResolver) CreatePersonalAccessToken(ctx context.Context, input models.CreatePersonalAccessTokenInput) (*models.CreatePersonalAccessTokenPayload, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.TokenService.CreateToken(ctx, me.ID, input)
+}
+
+// RevokePersonalAccessToken revokes one of the current user's access tokens
+func (r *mutationResolver) RevokePersonalAccessToken(ctx context.Context, id string) (bool, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.TokenService.RevokeToken(ctx, userID, id)
+}
+
+// RevokeSession signs out one of the current user's sessions
+func (r *mutationResolver) RevokeSession(ctx context.Context, id string) (bool, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// Only allow revoking sessions that belong to the caller
+	sessions, err := r.Authenticator.ListSessions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, session := range sessions {
+		if session.Id == id {
+			if err := r.Authenticator.RevokeSession(ctx, id); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
+	return false, errors.New("session not found")
+}
+
+// RevokeOtherSessions signs out every session except the current one and
+// returns how many were revoked
+func (r *mutationResolver) RevokeOtherSessions(ctx context.Context) (int, error) {
 	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return 0, err
+	}
+	current, _ := auth.GetUserFromContext(ctx)
+
+	sessions, err := r.Authenticator.ListSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if current != nil && session.Id == current.Id {
+			continue
+		}
+		if err := r.Authenticator.RevokeSession(ctx, session.Id); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	return revoked, nil
+}
+
+// DeleteAccount schedules the current user's account for deletion, which
+// also revokes their access tokens, and signs them out everywhere. Once the
+// deletion is scheduled it is reported as such even if revoking sessions
+// fails: the identity is already deactivated and the purge ends them anyway.
+func (r *mutationResolver) DeleteAccount(ctx context.Context) (*models.AccountDeletion, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deletion, err := r.UserService.ScheduleDeletion(ctx, me.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.Authenticator.RevokeIdentitySessions(ctx, me.ID); err != nil {
+		log.Printf("Failed to revoke sessions of %s after scheduling deletion: %v", me.ID, err)
+	}
+
+	return deletion, nil
+}
+
+// RestoreAccount cancels a pending account deletion on behalf of support
+func (r *mutationResolver) RestoreAccount(ctx context.Context, userID string) (*models.User, error) {
+	return r.UserService.RestoreAccount(ctx, userID)
+}
+
+// SuspendUser suspends a user on behalf of a moderator
+func (r *mutationResolver) SuspendUser(ctx context.Context, input models.SuspendUserInput) (*models.Suspension, error) {
+	moderatorID, account, err := auth.RequireAccount(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.SuspendUser(ctx, moderatorID, account.Role, input)
+}
+
+// UnsuspendUser lifts a user's active suspensions
+func (r *mutationResolver) UnsuspendUser(ctx context.Context, userID string) (bool, error) {
+	moderatorID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.UserService.UnsuspendUser(ctx, moderatorID, userID)
+}
+
+// ReportUser files a report about another user
+func (r *mutationResolver) ReportUser(ctx context.Context, input models.ReportUserInput) (bool, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return r.ModerationService.ReportUser(ctx, me.ID, input)
+}
+
+// ClaimReport puts a report in review with the current admin
+func (r *mutationResolver) ClaimReport(ctx context.Context, reportID string) (*models.Report, error) {
+	moderatorID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ModerationService.ClaimReport(ctx, moderatorID, reportID)
+}
+
+// ResolveReport takes action on a report on behalf of an admin
+func (r *mutationResolver) ResolveReport(ctx context.Context, input models.ResolveReportInput) (*models.Report, error) {
+	moderatorID, account, err := auth.RequireAccount(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.UserService.GetUserByID(ctx, userID)
+	return r.ModerationService.ResolveReport(ctx, moderatorID, account.Role, input)
+}
+
+// Me returns the currently authenticated user
+func (r *queryResolver) Me(ctx context.Context) (*models.User, error) {
+	return r.requireUser(ctx)
 }
 
 // User returns a user by ID
 func (r *queryResolver) User(ctx context.Context, id string) (*models.User, error) {
 	// Check authentication
-	_, err := auth.RequireAuth(ctx)
+	me, err := r.requireUser(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return r.UserService.GetUserByID(ctx, id)
+	return r.UserService.GetVisibleUserByID(ctx, me.ID, id)
+}
+
+// UserByHandle returns a user by their current or a previous handle
+func (r *queryResolver) UserByHandle(ctx context.Context, handle string) (*models.User, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.GetVisibleUserByHandle(ctx, me.ID, handle)
+}
+
+// CheckHandleAvailability reports whether the current user could take a handle
+func (r *queryResolver) CheckHandleAvailability(ctx context.Context, handle string) (*models.HandleAvailability, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.CheckHandleAvailability(ctx, userID, handle)
 }
 
 // SearchUsers searches for users based on the provided query
-func (r *queryResolver) SearchUsers(ctx context.Context, query string) ([]*models.User, error) {
+func (r *queryResolver) SearchUsers(ctx context.Context, query string, first *int, after *string) (*models.UserConnection, error) {
 	// Check authentication
-	_, err := auth.RequireAuth(ctx)
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.UserService.SearchUsers(ctx, me.ID, query, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return userConnection(page), nil
+}
+
+// SearchTravelers finds travelers by their travel preferences and interests
+func (r *queryResolver) SearchTravelers(ctx context.Context, filter *models.TravelerFilter, first *int, after *string) (*models.TravelerSearchResult, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.UserService.SearchTravelers(ctx, me.ID, filter, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	total, facets, err := r.UserService.TravelerFacets(ctx, me.ID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TravelerSearchResult{
+		Travelers:  userConnection(page),
+		TotalCount: total,
+		Facets:     facets,
+	}, nil
+}
+
+// NearbyTravelers finds travelers sharing their location near the current user
+func (r *queryResolver) NearbyTravelers(ctx context.Context, radiusKm float64, first *int, after *string) (*models.NearbyTravelerConnection, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.UserService.NearbyTravelers(ctx, me, radiusKm, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return nearbyTravelerConnection(page), nil
+}
+
+// SearchCities resolves a place name to cities that can be set as a home city
+func (r *queryResolver) SearchCities(ctx context.Context, query string, first *int) ([]*models.City, error) {
+	return r.UserService.SearchCities(query, first)
+}
+
+// SuggestedTravelers suggests people the current user may know or want to travel with
+func (r *queryResolver) SuggestedTravelers(ctx context.Context, first *int) ([]*models.TravelerSuggestion, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.SocialService.SuggestedTravelers(ctx, me, first)
+}
+
+// MyProfile returns the current user's full profile
+func (r *queryResolver) MyProfile(ctx context.Context) (*models.UserProfile, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.GetUserProfile(ctx, me)
+}
+
+// MyPrivacySettings returns the current user's privacy settings
+func (r *queryResolver) MyPrivacySettings(ctx context.Context) (*models.PrivacySettings, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.GetPrivacySettings(ctx, userID)
+}
+
+// IncomingFriendRequests lists the pending friend requests sent to the current user
+func (r *queryResolver) IncomingFriendRequests(ctx context.Context, first *int, after *string) (*models.FriendRequestConnection, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.SocialService.IncomingFriendRequests(ctx, me.ID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return friendRequestConnection(page), nil
+}
+
+// OutgoingFriendRequests lists the pending friend requests the current user sent
+func (r *queryResolver) OutgoingFriendRequests(ctx context.Context, first *int, after *string) (*models.FriendRequestConnection, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.SocialService.OutgoingFriendRequests(ctx, me.ID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return friendRequestConnection(page), nil
+}
+
+// BlockedUsers lists the users the current user blocked
+func (r *queryResolver) BlockedUsers(ctx context.Context, first *int, after *string) (*models.UserConnection, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.SocialService.BlockedUsers(ctx, me.ID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return userConnection(page), nil
+}
+
+// MutedUsers lists the users the current user muted
+func (r *queryResolver) MutedUsers(ctx context.Context, first *int, after *string) (*models.UserConnection, error) {
+	me, err := r.requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.SocialService.MutedUsers(ctx, me.ID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return userConnection(page), nil
+}
+
+// UserProfile returns a user's full profile by ID
+func (r *queryResolver) UserProfile(ctx context.Context, id string) (*models.UserProfile, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	user, err := r.UserService.GetVisibleUserByID(ctx, viewerID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.UserService.GetUserProfile(ctx, user)
+}
+
+// PersonalAccessTokens lists the current user's active access tokens
+func (r *queryResolver) PersonalAccessTokens(ctx context.Context, first *int, after *string) (*models.PersonalAccessTokenConnection, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := r.TokenService.ListTokens(ctx, userID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return personalAccessTokenConnection(page), nil
+}
+
+// MySessions lists where the current user is signed in, current session first.
+// Kratos returns every session at once, so pages are cut from that list
+func (r *queryResolver) MySessions(ctx context.Context, first *int, after *string) (*models.SessionConnection, error) {
+	userID, err := auth.RequireAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	current, ok := auth.GetUserFromContext(ctx)
+	if !ok {
+		return nil, auth.ErrNotAuthenticated
+	}
+
+	sessions, err := r.Authenticator.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*models.Session{sessionToModel(*current, current.Id)}
+	for _, session := range sessions {
+		if session.Id != current.Id {
+			result = append(result, sessionToModel(session, current.Id))
+		}
+	}
+
+	page, err := db.PageSlice(result, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return sessionConnection(page), nil
+}
+
+// SuspensionHistory lists a user's past and current suspensions
+func (r *queryResolver) SuspensionHistory(ctx context.Context, userID string, first *int, after *string) (*models.SuspensionConnection, error) {
+	page, err := r.UserService.SuspensionHistory(ctx, userID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return suspensionConnection(page), nil
+}
+
+// Reports lists the moderation queue, oldest reports first
+func (r *queryResolver) Reports(ctx context.Context, status *models.ReportStatus, userID *string, first *int, after *string) (*models.ReportConnection, error) {
+	page, err := r.ModerationService.Reports(ctx, status, userID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return reportConnection(page), nil
+}
+
+// Report returns a report by ID
+func (r *queryResolver) Report(ctx context.Context, id string) (*models.Report, error) {
+	return r.ModerationService.Report(ctx, id)
+}
+
+// ProfilePicture returns a signed URL for the requested size of an uploaded
+// profile picture, or the profile picture URL the user set
+func (r *userResolver) ProfilePicture(ctx context.Context, obj *models.User, size *models.ProfilePictureSize) (*string, error) {
+	if obj.ProfilePictureKey == nil {
+		return obj.ProfilePicture, nil
+	}
+
+	pictureSize := models.ProfilePictureSizeMedium
+	if size != nil {
+		pictureSize = *size
+	}
+
+	url := r.MediaSigner.URL(user.ProfilePictureKey(*obj.ProfilePictureKey, pictureSize))
+	return &url, nil
+}
+
+// TravelPreferences returns the travel preferences of a user
+func (r *userResolver) TravelPreferences(ctx context.Context, obj *models.User) (*models.TravelPreferences, error) {
+	return r.UserService.GetTravelPreferences(ctx, obj.ID)
+}
+
+// HomeCity returns the user's home city
+func (r *userResolver) HomeCity(ctx context.Context, obj *models.User) (*models.City, error) {
+	return user.HomeCity(obj), nil
+}
+
+// CurrentLocation returns the location a user shares. Only its owner can see
+// it; other users only learn how far away it is through nearbyTravelers.
+func (r *userResolver) CurrentLocation(ctx context.Context, obj *models.User) (*models.Location, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	if viewerID != obj.ID {
+		return nil, nil
+	}
+
+	return r.UserService.GetCurrentLocation(ctx, obj.ID)
+}
+
+// Followers lists the users following a user, most recent first
+func (r *userResolver) Followers(ctx context.Context, obj *models.User, first *int, after *string) (*models.UserConnection, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	page, err := r.SocialService.Followers(ctx, viewerID, obj.ID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return userConnection(page), nil
+}
+
+// Following lists the users a user follows, most recently followed first
+func (r *userResolver) Following(ctx context.Context, obj *models.User, first *int, after *string) (*models.UserConnection, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	page, err := r.SocialService.Following(ctx, viewerID, obj.ID, db.PageArgs{First: first, After: after})
 	if err != nil {
 		return nil, err
 	}
 
-	return r.UserService.SearchUsers(ctx, query)
+	return userConnection(page), nil
 }
 
+// FollowerCount returns how many users follow a user
+func (r *userResolver) FollowerCount(ctx context.Context, obj *models.User) (int, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	return r.SocialService.FollowerCount(ctx, viewerID, obj.ID)
+}
+
+// FollowingCount returns how many users a user follows
+func (r *userResolver) FollowingCount(ctx context.Context, obj *models.User) (int, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	return r.SocialService.FollowingCount(ctx, viewerID, obj.ID)
+}
+
+// IsFollowedByMe reports whether the current user follows a user
+func (r *userResolver) IsFollowedByMe(ctx context.Context, obj *models.User) (bool, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	return isFollower(ctx, r.SocialService, viewerID, obj.ID)
+}
+
+// Friends lists a user's friends, most recent friendships first
+func (r *userResolver) Friends(ctx context.Context, obj *models.User, first *int, after *string) (*models.UserConnection, error) {
+	viewerID, _ := auth.GetUserIDFromContext(ctx)
+	page, err := r.SocialService.Friends(ctx, viewerID, obj.ID, db.PageArgs{First: first, After: after})
+	if err != nil {
+		return nil, err
+	}
+
+	return userConnection(page), nil
+}
+
+// FriendRequest returns generated.FriendRequestResolver implementation.
+func (r *Resolver) FriendRequest() generated.FriendRequestResolver { return &friendRequestResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// User returns generated.UserResolver implementation.
+func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
+
+type friendRequestResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,45 @@
+package graph
+
+import (
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	kratosclient "github.com/ory/kratos-client-go"
+)
+
+// sessionToModel converts a Kratos session to its GraphQL representation
+func sessionToModel(session kratosclient.Session, currentID string) *models.Session {
+	result := &models.Session{
+		ID:              session.Id,
+		Current:         session.Id == currentID,
+		AuthenticatedAt: formatOptionalTime(session.AuthenticatedAt),
+		IssuedAt:        formatOptionalTime(session.IssuedAt),
+		ExpiresAt:       formatOptionalTime(session.ExpiresAt),
+		Devices:         []*models.SessionDevice{},
+	}
+
+	if session.AuthenticatorAssuranceLevel != nil {
+		aal := string(*session.AuthenticatorAssuranceLevel)
+		result.Aal = &aal
+	}
+
+	for _, device := range session.Devices {
+		result.Devices = append(result.Devices, &models.SessionDevice{
+			ID:        device.Id,
+			IPAddress: device.IpAddress,
+			UserAgent: device.UserAgent,
+			Location:  device.Location,
+		})
+	}
+
+	return result
+}
+
+// formatOptionalTime formats a nullable timestamp as RFC 3339
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+	formatted := t.Format(time.RFC3339)
+	return &formatted
+}
@@ -0,0 +1,86 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// ErrUnsupportedFormat is returned for data that isn't a JPEG, PNG or WebP image
+var ErrUnsupportedFormat = errors.New("unsupported image format, use JPEG, PNG or WebP")
+
+// maxPixels bounds the size of images that will be decoded, so a small file
+// can't claim huge dimensions and exhaust memory
+const maxPixels = 40_000_000
+
+// jpegQuality is used for every encoded thumbnail
+const jpegQuality = 85
+
+// Image is a decoded image together with the EXIF orientation it was stored with
+type Image struct {
+	image.Image
+	orientation int
+}
+
+// Decode decodes a JPEG, PNG or WebP image. Only the pixels are kept, so
+// anything derived from the result carries no EXIF, XMP or other metadata.
+// The EXIF orientation of JPEGs is remembered and applied to thumbnails.
+func Decode(data []byte) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("image dimensions %dx%d are too large", config.Width, config.Height)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s image: %w", format, err)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = exifOrientation(data)
+	}
+
+	return &Image{
+		Image:       img,
+		orientation: orientation,
+	}, nil
+}
+
+// SquareThumbnail crops the centre square of the image, scales it to
+// size x size and turns it upright. Transparent areas are flattened onto white.
+func (img *Image) SquareThumbnail(size int) image.Image {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Pt(
+		bounds.Min.X+(bounds.Dx()-side)/2,
+		bounds.Min.Y+(bounds.Dy()-side)/2,
+	))
+
+	thumb := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(thumb, thumb.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(thumb, thumb.Bounds(), img.Image, crop, draw.Over, nil)
+
+	// The centre square of a rotated or flipped image is the rotated or
+	// flipped centre square, so orienting the small thumbnail is enough
+	return orient(thumb, img.orientation)
+}
+
+// EncodeJPEG encodes an image as a JPEG without any metadata
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
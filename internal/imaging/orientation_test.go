@@ -0,0 +1,134 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// jpegWithOrientation builds the segments of a JPEG up to the image data
+// whose APP1 segment holds a TIFF header with a single orientation entry
+func jpegWithOrientation(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], orientationTag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	return jpegWithSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+// jpegWithSegment builds a JPEG start followed by one segment and the start of scan
+func jpegWithSegment(marker byte, payload []byte) []byte {
+	data := []byte{0xFF, 0xD8, 0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(data[4:], uint16(len(payload)+2))
+	data = append(data, payload...)
+	return append(data, 0xFF, 0xDA, 0, 2)
+}
+
+func TestExifOrientation(t *testing.T) {
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			if got := exifOrientation(jpegWithOrientation(order, orientation)); got != int(orientation) {
+				t.Errorf("exifOrientation(%v, %d) = %d", order, orientation, got)
+			}
+		}
+	}
+
+	valid := jpegWithOrientation(binary.BigEndian, 6)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n")},
+		{"no exif", jpegWithSegment(0xE0, []byte("JFIF\x00\x01\x02"))},
+		{"out of range value", jpegWithOrientation(binary.BigEndian, 9)},
+		{"truncated segment", valid[:20]},
+		{"bad segment length", append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}, valid[6:]...)},
+		{"bad byte order", append(append([]byte{}, valid[:12]...), append([]byte("XX"), valid[14:]...)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exifOrientation(tt.data); got != 1 {
+				t.Errorf("exifOrientation = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// A 2x3 image whose top-left pixel is red and top-right pixel is blue
+	const w, h = 2, 3
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	src := image.NewRGBA(image.Rect(0, 0, w, h))
+	src.SetRGBA(0, 0, red)
+	src.SetRGBA(w-1, 0, blue)
+
+	type corner int
+	const (
+		topLeft corner = iota
+		topRight
+		bottomLeft
+		bottomRight
+	)
+	at := func(img image.Image, c corner) color.Color {
+		b := img.Bounds()
+		switch c {
+		case topRight:
+			return img.At(b.Max.X-1, 0)
+		case bottomLeft:
+			return img.At(0, b.Max.Y-1)
+		case bottomRight:
+			return img.At(b.Max.X-1, b.Max.Y-1)
+		}
+		return img.At(0, 0)
+	}
+
+	tests := []struct {
+		orientation int
+		rotated     bool
+		red, blue   corner
+	}{
+		{1, false, topLeft, topRight},
+		{2, false, topRight, topLeft},
+		{3, false, bottomRight, bottomLeft},
+		{4, false, bottomLeft, bottomRight},
+		{5, true, topLeft, bottomLeft},
+		{6, true, topRight, bottomRight},
+		{7, true, bottomRight, topRight},
+		{8, true, bottomLeft, topLeft},
+		{0, false, topLeft, topRight},
+		{9, false, topLeft, topRight},
+	}
+
+	for _, tt := range tests {
+		got := orient(src, tt.orientation)
+
+		wantW, wantH := w, h
+		if tt.rotated {
+			wantW, wantH = h, w
+		}
+		if b := got.Bounds(); b.Dx() != wantW || b.Dy() != wantH {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), wantW, wantH)
+			continue
+		}
+		if at(got, tt.red) != red {
+			t.Errorf("orientation %d: top-left pixel not moved to corner %d", tt.orientation, tt.red)
+		}
+		if at(got, tt.blue) != blue {
+			t.Errorf("orientation %d: top-right pixel not moved to corner %d", tt.orientation, tt.blue)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// enumFromModel converts a GraphQL enum value to the value stored in user_reports
+func enumFromModel[T ~string](value T) string {
+	return strings.ToLower(string(value))
+}
+
+// enumToModel converts a value stored in user_reports to its GraphQL enum value
+func enumToModel[T ~string](value string) T {
+	return T(strings.ToUpper(value))
+}
+
+// reportColumns lists the user_reports columns read by scanReport, in order
+const reportColumns = `id, reporter_id, reported_id, category, details, snapshot, status, assigned_to,
+		       action, action_note, suspension_id, resolved_by, resolved_at, created_at`
+
+// scanReport reads a user_reports row selected with reportColumns
+func scanReport(row db.RowScanner) (*models.Report, error) {
+	var report models.Report
+	var reporterID, reportedID, details, assignedTo, action, actionNote, suspensionID, resolvedBy sql.NullString
+	var category, status string
+	var snapshot []byte
+	var resolvedAt sql.NullTime
+	var createdAt time.Time
+
+	err := row.Scan(
+		&report.ID, &reporterID, &reportedID, &category, &details, &snapshot, &status,
+		&assignedTo, &action, &actionNote, &suspensionID, &resolvedBy, &resolvedAt, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(snapshot, &report.Snapshot); err != nil {
+		return nil, fmt.Errorf("error decoding profile snapshot: %w", err)
+	}
+	report.Category = enumToModel[models.ReportCategory](category)
+	report.Status = enumToModel[models.ReportStatus](status)
+	if action.Valid {
+		a := enumToModel[models.ModerationAction](action.String)
+		report.Action = &a
+	}
+	if reporterID.Valid {
+		report.ReporterID = &reporterID.String
+	}
+	if reportedID.Valid {
+		report.ReportedUserID = &reportedID.String
+	}
+	if details.Valid {
+		report.Details = &details.String
+	}
+	if assignedTo.Valid {
+		report.AssignedTo = &assignedTo.String
+	}
+	if actionNote.Valid {
+		report.ActionNote = &actionNote.String
+	}
+	if suspensionID.Valid {
+		report.SuspensionID = &suspensionID.String
+	}
+	if resolvedBy.Valid {
+		report.ResolvedBy = &resolvedBy.String
+	}
+	if resolvedAt.Valid {
+		formatted := resolvedAt.Time.Format(time.RFC3339)
+		report.ResolvedAt = &formatted
+	}
+	report.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return &report, nil
+}
+
+// CreateReport files a report along with a snapshot of the reported profile
+func (r *Repository) CreateReport(ctx context.Context, reporterID, reportedID string, category models.ReportCategory, details *string, snapshot *models.ProfileSnapshot) (*models.Report, error) {
+	encoded, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding profile snapshot: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_reports (id, reporter_id, reported_id, category, details, snapshot)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5)
+		RETURNING ` + reportColumns + `
+	`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query,
+		reporterID, reportedID, enumFromModel(category), details, encoded))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == db.UniqueViolation {
+			return nil, ErrAlreadyReported
+		}
+		return nil, fmt.Errorf("error creating report: %w", err)
+	}
+
+	return report, nil
+}
+
+// GetReport returns a report by ID
+func (r *Repository) GetReport(ctx context.Context, id string) (*models.Report, error) {
+	query := `
+		SELECT ` + reportColumns + `
+		FROM user_reports
+		WHERE id = $1
+	`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("report not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying report: %w", err)
+	}
+
+	return report, nil
+}
+
+// ListReports returns a page of reports, oldest first so the queue is worked
+// through in the order it filled up, optionally narrowed down to a status
+// and to the reports about one user
+func (r *Repository) ListReports(ctx context.Context, status *models.ReportStatus, reportedID *string, args db.PageArgs) (*db.Page[*models.Report], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
+
+	var statusFilter *string
+	if status != nil {
+		s := enumFromModel(*status)
+		statusFilter = &s
+	}
+
+	query := `
+		SELECT ` + reportColumns + `, created_at
+		FROM user_reports
+		WHERE ($1::text IS NULL OR status = $1) AND
+			($2::text IS NULL OR reported_id = $2) AND (
+				$3::timestamptz IS NULL OR
+				created_at > $3 OR
+				(created_at = $3 AND id > $4)
+			)
+		ORDER BY created_at, id
+		LIMIT $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, statusFilter, reportedID, afterCreatedAt, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error listing reports: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*models.Report, []interface{}, error) {
+		var createdAt time.Time
+		report, err := scanReport(db.WithExtra(rows, &createdAt))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning report row: %w", err)
+		}
+		return report, []interface{}{createdAt, report.ID}, nil
+	})
+}
+
+// ClaimReport puts an unresolved report in review with the moderator
+// assigned to it, taking it over from any moderator reviewing it before
+func (r *Repository) ClaimReport(ctx context.Context, id, moderatorID string) (*models.Report, error) {
+	query := `
+		UPDATE user_reports
+		SET status = 'in_review', assigned_to = $2
+		WHERE id = $1 AND status IN ('open', 'in_review')
+		RETURNING ` + reportColumns + `
+	`
+
+	report, err := scanReport(r.db.QueryRowContext(ctx, query, id, moderatorID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.unresolvedError(ctx, id)
+		}
+		return nil, fmt.Errorf("error claiming report: %w", err)
+	}
+
+	return report, nil
+}
+
+// ResolveReport locks an unresolved report, runs act on it and records the
+// action a moderator took along with the suspension act returns, if any.
+// Dismissing a report leaves it dismissed; any other action leaves it
+// actioned. act writes within the same transaction, so the action and the
+// resolution commit together: concurrent resolutions of the same report wait
+// for this one and then find it resolved, and when anything fails neither
+// the action nor the resolution is kept.
+func (r *Repository) ResolveReport(ctx context.Context, id, moderatorID string, action models.ModerationAction, note *string, act func(tx *sql.Tx, report *models.Report) (*string, error)) (*models.Report, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	report, err := scanReport(tx.QueryRowContext(ctx,
+		"SELECT "+reportColumns+" FROM user_reports WHERE id = $1 FOR UPDATE", id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("report not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying report: %w", err)
+	}
+	if report.Status != models.ReportStatusOpen && report.Status != models.ReportStatusInReview {
+		return nil, fmt.Errorf("report was already %s", enumFromModel(report.Status))
+	}
+
+	suspensionID, err := act(tx, report)
+	if err != nil {
+		return nil, err
+	}
+
+	status := "actioned"
+	if action == models.ModerationActionDismiss {
+		status = "dismissed"
+	}
+
+	query := `
+		UPDATE user_reports
+		SET status = $3, action = $4, action_note = $5, suspension_id = $6,
+			resolved_by = $2, resolved_at = NOW(), assigned_to = COALESCE(assigned_to, $2)
+		WHERE id = $1
+		RETURNING ` + reportColumns + `
+	`
+
+	report, err = scanReport(tx.QueryRowContext(ctx, query,
+		id, moderatorID, status, enumFromModel(action), note, suspensionID))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving report: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing report resolution: %w", err)
+	}
+
+	return report, nil
+}
+
+// unresolvedError explains why a report that was expected to be unresolved
+// couldn't be updated
+func (r *Repository) unresolvedError(ctx context.Context, id string) error {
+	report, err := r.GetReport(ctx, id)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("report was already %s", enumFromModel(report.Status))
+}
@@ -0,0 +1,196 @@
+package moderation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+)
+
+// maxDetailsLength caps the free text a reporter can add to a report, in characters
+const maxDetailsLength = 2000
+
+// ErrAlreadyReported is returned when the reporter already has an unresolved
+// report about the same user
+var ErrAlreadyReported = errors.New("you have already reported this user")
+
+// Service manages user reports and the moderation queue they feed. Taking
+// action on the reported user is left to the user service.
+type Service struct {
+	repo  *Repository
+	users *user.Service
+}
+
+func NewService(repo *Repository, users *user.Service) *Service {
+	return &Service{
+		repo:  repo,
+		users: users,
+	}
+}
+
+// ReportUser files a report about another user. Reporters can only report
+// users they can see, the same rule as any other lookup, so hidden accounts,
+// including either side of a block, are not found; someone being harassed
+// reports first and blocks afterwards. The reported profile is captured as it
+// is at report time, so moderators see what was reported even if the user
+// edits their profile afterwards.
+func (s *Service) ReportUser(ctx context.Context, reporterID string, input models.ReportUserInput) (bool, error) {
+	if input.UserID == reporterID {
+		return false, errors.New("you can't report yourself")
+	}
+
+	var details *string
+	if input.Details != nil {
+		trimmed := strings.TrimSpace(*input.Details)
+		if utf8.RuneCountInString(trimmed) > maxDetailsLength {
+			return false, fmt.Errorf("details must be at most %d characters", maxDetailsLength)
+		}
+		if trimmed != "" {
+			details = &trimmed
+		}
+	}
+	if input.Category == models.ReportCategoryOther && details == nil {
+		return false, errors.New("details are required for reports in the OTHER category")
+	}
+
+	snapshot, err := s.snapshotProfile(ctx, reporterID, input.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := s.repo.CreateReport(ctx, reporterID, input.UserID, input.Category, details, snapshot); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// snapshotProfile captures the parts of a user's profile a report may be
+// about, provided the reporter can see them
+func (s *Service) snapshotProfile(ctx context.Context, reporterID, userID string) (*models.ProfileSnapshot, error) {
+	reported, err := s.users.GetVisibleUserByID(ctx, reporterID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.users.GetUserProfile(ctx, reported)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ProfileSnapshot{
+		Handle:             reported.Handle,
+		FirstName:          reported.FirstName,
+		LastName:           reported.LastName,
+		Bio:                reported.Bio,
+		Interests:          reported.Interests,
+		ProfilePicture:     reported.ProfilePicture,
+		HasUploadedPicture: reported.ProfilePictureKey != nil,
+		TravelPreferences:  profile.TravelPreferences,
+		CapturedAt:         time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// Reports returns a page of the moderation queue, oldest reports first
+func (s *Service) Reports(ctx context.Context, status *models.ReportStatus, reportedID *string, args db.PageArgs) (*db.Page[*models.Report], error) {
+	return s.repo.ListReports(ctx, status, reportedID, args)
+}
+
+// Report returns a report by ID
+func (s *Service) Report(ctx context.Context, id string) (*models.Report, error) {
+	return s.repo.GetReport(ctx, id)
+}
+
+// ClaimReport assigns an unresolved report to the moderator and puts it in review
+func (s *Service) ClaimReport(ctx context.Context, moderatorID, reportID string) (*models.Report, error) {
+	report, err := s.repo.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireUninvolved(report, moderatorID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.ClaimReport(ctx, reportID, moderatorID)
+}
+
+// ResolveReport takes action on an unresolved report and records it along
+// with the acting moderator. SUSPEND and REMOVE_CONTENT act on the reported
+// user, which like any suspension needs a role above theirs; the note doubles
+// as the suspension reason. WARN and DISMISS are only recorded. The action is
+// written in the same transaction as the resolution, so a report is only ever
+// acted on once and stays unresolved if anything fails. Removed profile
+// pictures are only deleted from storage once that has committed.
+func (s *Service) ResolveReport(ctx context.Context, moderatorID string, moderatorRole auth.Role, input models.ResolveReportInput) (*models.Report, error) {
+	if input.DurationHours != nil && input.Action != models.ModerationActionSuspend {
+		return nil, errors.New("durationHours only applies to the SUSPEND action")
+	}
+
+	var note *string
+	if input.Note != nil {
+		if trimmed := strings.TrimSpace(*input.Note); trimmed != "" {
+			note = &trimmed
+		}
+	}
+
+	var deletePicture func()
+	report, err := s.repo.ResolveReport(ctx, input.ReportID, moderatorID, input.Action, note, func(tx *sql.Tx, report *models.Report) (*string, error) {
+		if err := requireUninvolved(report, moderatorID); err != nil {
+			return nil, err
+		}
+		if report.ReportedUserID == nil && input.Action != models.ModerationActionDismiss {
+			return nil, errors.New("the reported account no longer exists, so the report can only be dismissed")
+		}
+
+		switch input.Action {
+		case models.ModerationActionSuspend:
+			reason := "Reported for " + strings.ReplaceAll(enumFromModel(report.Category), "_", " ")
+			if note != nil {
+				reason = *note
+			}
+
+			suspension, err := s.users.SuspendUserTx(ctx, tx, moderatorID, moderatorRole, models.SuspendUserInput{
+				UserID:        *report.ReportedUserID,
+				Reason:        reason,
+				DurationHours: input.DurationHours,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return &suspension.ID, nil
+		case models.ModerationActionRemoveContent:
+			_, cleanup, err := s.users.RemoveProfileContent(ctx, tx, moderatorID, moderatorRole, *report.ReportedUserID)
+			if err != nil {
+				return nil, err
+			}
+			deletePicture = cleanup
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deletePicture != nil {
+		deletePicture()
+	}
+	return report, nil
+}
+
+// requireUninvolved rejects moderators handling reports about themselves
+func requireUninvolved(report *models.Report, moderatorID string) error {
+	if report.ReportedUserID != nil && *report.ReportedUserID == moderatorID {
+		return &auth.Error{
+			Code:    auth.CodeForbidden,
+			Message: "cannot handle a report about yourself",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+)
+
+// pendingDeletion reports whether the user's account is scheduled for deletion
+func (ts *testServer) pendingDeletion(t *testing.T, id string) bool {
+	t.Helper()
+
+	var pending bool
+	err := ts.db.QueryRow(`SELECT deletion_requested_at IS NOT NULL FROM users WHERE id = $1`, id).Scan(&pending)
+	if err != nil {
+		t.Fatalf("failed to read deletion state: %v", err)
+	}
+	return pending
+}
+
+func TestAccountDeletionAndRestore(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.newUserWithRole(t, auth.RoleAdmin)
+	leaving := ts.newUser(t)
+	viewer := ts.newUser(t)
+
+	var created struct {
+		CreatePersonalAccessToken struct{ Token string }
+	}
+	leaving.mustDo(t, `mutation { createPersonalAccessToken(input: {name: "ci", scopes: ["read:profile"]}) { token } }`, nil, &created)
+	pat := leaving.withToken(created.CreatePersonalAccessToken.Token)
+
+	var deletion struct {
+		DeleteAccount struct{ ScheduledFor string }
+	}
+	leaving.mustDo(t, `mutation { deleteAccount { scheduledFor } }`, nil, &deletion)
+
+	scheduledFor, err := time.Parse(time.RFC3339, deletion.DeleteAccount.ScheduledFor)
+	if err != nil {
+		t.Fatalf("scheduledFor: %v", err)
+	}
+	if !scheduledFor.After(time.Now()) {
+		t.Errorf("deletion scheduled for %v, which is not in the future", scheduledFor)
+	}
+	if state := ts.kratos.state(leaving.id); state != "inactive" {
+		t.Errorf("identity state = %q, want inactive", state)
+	}
+	if !ts.pendingDeletion(t, leaving.id) {
+		t.Error("account is not pending deletion")
+	}
+
+	// The user's sessions and tokens stop working and they are hidden from everyone else
+	if rec := leaving.post(t, `{ me { id } }`, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("session after deletion: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if rec := pat.post(t, `{ me { id } }`, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("access token after deletion: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if visible(t, viewer, leaving.id) {
+		t.Error("account pending deletion is still visible")
+	}
+
+	var restored struct {
+		RestoreAccount struct{ ID string }
+	}
+	admin.mustDo(t, `mutation($id: ID!) { restoreAccount(userId: $id) { id } }`,
+		map[string]interface{}{"id": leaving.id}, &restored)
+	if restored.RestoreAccount.ID != leaving.id {
+		t.Errorf("restored %s, want %s", restored.RestoreAccount.ID, leaving.id)
+	}
+	if state := ts.kratos.state(leaving.id); state != "active" {
+		t.Errorf("identity state = %q, want active", state)
+	}
+	if !visible(t, viewer, leaving.id) {
+		t.Error("restored account is hidden")
+	}
+
+	admin.expectError(t, "no pending deletion", `mutation($id: ID!) { restoreAccount(userId: $id) { id } }`,
+		map[string]interface{}{"id": leaving.id})
+	viewer.expectCode(t, auth.CodeForbidden, `mutation($id: ID!) { restoreAccount(userId: $id) { id } }`,
+		map[string]interface{}{"id": leaving.id})
+}
+
+func TestAccountDeletionRollsBackWhenKratosFails(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.newUserWithRole(t, auth.RoleAdmin)
+	viewer := ts.newUser(t)
+
+	t.Run("schedule", func(t *testing.T) {
+		leaving := ts.newUser(t)
+		ts.kratos.setFailing(leaving.id, true)
+		defer ts.kratos.setFailing(leaving.id, false)
+
+		if resp := leaving.do(t, `mutation { deleteAccount { scheduledFor } }`, nil, nil); len(resp.Errors) == 0 {
+			t.Fatal("deleteAccount succeeded although the identity couldn't be deactivated")
+		}
+		if ts.pendingDeletion(t, leaving.id) {
+			t.Error("deletion is still pending after the identity error")
+		}
+		if !visible(t, viewer, leaving.id) {
+			t.Error("account is hidden after the failed deletion")
+		}
+		// Sessions are only revoked once the deletion went through
+		leaving.mustDo(t, `{ me { id } }`, nil, nil)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		leaving := ts.newUser(t)
+		leaving.mustDo(t, `mutation { deleteAccount { scheduledFor } }`, nil, nil)
+
+		ts.kratos.setFailing(leaving.id, true)
+		defer ts.kratos.setFailing(leaving.id, false)
+
+		vars := map[string]interface{}{"id": leaving.id}
+		if resp := admin.do(t, `mutation($id: ID!) { restoreAccount(userId: $id) { id } }`, vars, nil); len(resp.Errors) == 0 {
+			t.Fatal("restoreAccount succeeded although the identity couldn't be reactivated")
+		}
+		if !ts.pendingDeletion(t, leaving.id) {
+			t.Error("deletion was not put back after the identity error")
+		}
+		if visible(t, viewer, leaving.id) {
+			t.Error("account is visible although its deletion is still pending")
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+)
+
+func TestAuthDirectiveRequiresAuthentication(t *testing.T) {
+	ts := newTestServer(t)
+	anonymous := &client{ts: ts}
+
+	anonymous.expectCode(t, auth.CodeUnauthenticated, `{ me { id } }`, nil)
+	anonymous.expectCode(t, auth.CodeUnauthenticated, `mutation { updateProfile(input: {bio: "hi"}) { id } }`, nil)
+
+	rec := anonymous.withToken("not-a-session").post(t, `{ me { id } }`, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown session token: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestHasRoleDirective(t *testing.T) {
+	ts := newTestServer(t)
+	member := ts.newUser(t)
+	moderator := ts.newUserWithRole(t, auth.RoleModerator)
+	admin := ts.newUserWithRole(t, auth.RoleAdmin)
+	target := ts.newUser(t)
+
+	history := `query($id: ID!) { suspensionHistory(userId: $id) { edges { node { id } } } }`
+	vars := map[string]interface{}{"id": target.id}
+	member.expectCode(t, auth.CodeForbidden, history, vars)
+	moderator.mustDo(t, history, vars, nil)
+	// Roles include the privileges of the roles below them
+	admin.mustDo(t, history, vars, nil)
+
+	setRole := `mutation($id: ID!) { setUserRole(userId: $id, role: MODERATOR) { role } }`
+	moderator.expectCode(t, auth.CodeForbidden, setRole, vars)
+
+	var promoted struct {
+		SetUserRole struct{ Role string }
+	}
+	admin.mustDo(t, setRole, vars, &promoted)
+	if promoted.SetUserRole.Role != "MODERATOR" {
+		t.Errorf("role = %s, want MODERATOR", promoted.SetUserRole.Role)
+	}
+
+	admin.expectCode(t, auth.CodeForbidden, `mutation($id: ID!) { setUserRole(userId: $id, role: USER) { role } }`,
+		map[string]interface{}{"id": admin.id})
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlocksHideUsersBothWays(t *testing.T) {
+	ts := newTestServer(t)
+	blocker := ts.newUser(t)
+	blocked := ts.newUser(t)
+
+	handle := "b" + strings.ReplaceAll(blocked.id, "-", "")[:20]
+	blocked.mustDo(t, `mutation($handle: String!) { setHandle(handle: $handle) { id } }`, map[string]interface{}{"handle": handle}, nil)
+
+	// Existing relations end with the block
+	blocker.mustDo(t, `mutation($id: ID!) { follow(userId: $id) { id } }`, map[string]interface{}{"id": blocked.id}, nil)
+	blocked.mustDo(t, `mutation($id: ID!) { sendFriendRequest(userId: $id) { id } }`, map[string]interface{}{"id": blocker.id}, nil)
+
+	var result struct{ BlockUser bool }
+	blocker.mustDo(t, `mutation($id: ID!) { blockUser(userId: $id) }`, map[string]interface{}{"id": blocked.id}, &result)
+	if !result.BlockUser {
+		t.Fatal("blockUser returned false")
+	}
+
+	if visible(t, blocker, blocked.id) {
+		t.Error("blocker can still see the blocked user")
+	}
+	if visible(t, blocked, blocker.id) {
+		t.Error("blocked user can still see the blocker")
+	}
+
+	searchHandle := func(t *testing.T, viewer *client) int {
+		t.Helper()
+		var out struct {
+			SearchUsers struct {
+				Edges []struct{ Node struct{ ID string } }
+			}
+		}
+		viewer.mustDo(t, `query($q: String!) { searchUsers(query: $q) { edges { node { id } } } }`, map[string]interface{}{"q": handle}, &out)
+		return len(out.SearchUsers.Edges)
+	}
+	if n := searchHandle(t, blocker); n != 0 {
+		t.Errorf("blocked user found by search %d times", n)
+	}
+
+	var me struct {
+		Me struct{ FollowingCount int }
+	}
+	blocker.mustDo(t, `{ me { followingCount } }`, nil, &me)
+	if me.Me.FollowingCount != 0 {
+		t.Errorf("follow survived the block: following %d", me.Me.FollowingCount)
+	}
+	var incoming struct {
+		IncomingFriendRequests struct {
+			Edges []struct{ Node struct{ ID string } }
+		}
+	}
+	blocker.mustDo(t, `{ incomingFriendRequests { edges { node { id } } } }`, nil, &incoming)
+	if n := len(incoming.IncomingFriendRequests.Edges); n != 0 {
+		t.Errorf("%d friend requests survived the block", n)
+	}
+
+	// Neither side can reach the other
+	blocked.expectError(t, "not found", `mutation($id: ID!) { follow(userId: $id) { id } }`, map[string]interface{}{"id": blocker.id})
+	blocker.expectError(t, "not found", `mutation($id: ID!) { sendFriendRequest(userId: $id) { id } }`, map[string]interface{}{"id": blocked.id})
+
+	var blockedList struct {
+		BlockedUsers struct {
+			Edges []struct{ Node struct{ ID string } }
+		}
+	}
+	blocker.mustDo(t, `{ blockedUsers { edges { node { id } } } }`, nil, &blockedList)
+	if edges := blockedList.BlockedUsers.Edges; len(edges) != 1 || edges[0].Node.ID != blocked.id {
+		t.Errorf("blockedUsers = %+v", edges)
+	}
+
+	blocker.mustDo(t, `mutation($id: ID!) { unblockUser(userId: $id) }`, map[string]interface{}{"id": blocked.id}, nil)
+	if !visible(t, blocker, blocked.id) || !visible(t, blocked, blocker.id) {
+		t.Error("users are still hidden after unblocking")
+	}
+	if n := searchHandle(t, blocker); n != 1 {
+		t.Errorf("unblocked user found by search %d times, want 1", n)
+	}
+}
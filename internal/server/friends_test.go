@@ -0,0 +1,137 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFriendRequestStateMachine(t *testing.T) {
+	ts := newTestServer(t)
+
+	type friendRequest struct {
+		ID        string
+		Status    string
+		Sender    *struct{ ID string }
+		Recipient *struct{ ID string }
+	}
+	const fields = `id status sender { id } recipient { id }`
+
+	send := func(t *testing.T, from, to *client) friendRequest {
+		t.Helper()
+		var out struct{ SendFriendRequest friendRequest }
+		from.mustDo(t, `mutation($id: ID!) { sendFriendRequest(userId: $id) { `+fields+` } }`, map[string]interface{}{"id": to.id}, &out)
+		return out.SendFriendRequest
+	}
+	respond := func(t *testing.T, c *client, id, response string) *gqlResponse {
+		t.Helper()
+		return c.do(t, `mutation($id: ID!, $r: FriendRequestResponse!) { respondToFriendRequest(requestId: $id, response: $r) { `+fields+` } }`,
+			map[string]interface{}{"id": id, "r": response}, nil)
+	}
+	cancel := `mutation($id: ID!) { cancelFriendRequest(requestId: $id) { ` + fields + ` } }`
+
+	t.Run("accept", func(t *testing.T) {
+		sender, recipient := ts.newUser(t), ts.newUser(t)
+
+		request := send(t, sender, recipient)
+		if request.Status != "PENDING" || request.Sender == nil || request.Sender.ID != sender.id ||
+			request.Recipient == nil || request.Recipient.ID != recipient.id {
+			t.Fatalf("unexpected request %+v", request)
+		}
+
+		sender.expectError(t, "already pending", `mutation($id: ID!) { sendFriendRequest(userId: $id) { id } }`, map[string]interface{}{"id": recipient.id})
+		recipient.expectError(t, "respond to it instead", `mutation($id: ID!) { sendFriendRequest(userId: $id) { id } }`, map[string]interface{}{"id": sender.id})
+		// Only the recipient responds
+		if resp := respond(t, sender, request.ID, "ACCEPT"); len(resp.Errors) == 0 {
+			t.Error("sender accepted their own request")
+		}
+
+		var accepted struct{ RespondToFriendRequest friendRequest }
+		recipient.mustDo(t, `mutation($id: ID!) { respondToFriendRequest(requestId: $id, response: ACCEPT) { `+fields+` } }`,
+			map[string]interface{}{"id": request.ID}, &accepted)
+		if accepted.RespondToFriendRequest.Status != "ACCEPTED" {
+			t.Errorf("status = %s, want ACCEPTED", accepted.RespondToFriendRequest.Status)
+		}
+
+		if resp := respond(t, recipient, request.ID, "DECLINE"); len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, "already accepted") {
+			t.Errorf("responding twice: %+v", resp.Errors)
+		}
+		sender.expectError(t, "already friends", `mutation($id: ID!) { sendFriendRequest(userId: $id) { id } }`, map[string]interface{}{"id": recipient.id})
+
+		var friends struct {
+			Me struct {
+				Friends struct {
+					Edges []struct{ Node struct{ ID string } }
+				}
+			}
+		}
+		sender.mustDo(t, `{ me { friends { edges { node { id } } } } }`, nil, &friends)
+		if edges := friends.Me.Friends.Edges; len(edges) != 1 || edges[0].Node.ID != recipient.id {
+			t.Errorf("friends = %+v", edges)
+		}
+
+		var removed struct{ RemoveFriend bool }
+		recipient.mustDo(t, `mutation($id: ID!) { removeFriend(userId: $id) }`, map[string]interface{}{"id": sender.id}, &removed)
+		if !removed.RemoveFriend {
+			t.Error("removeFriend returned false")
+		}
+		send(t, sender, recipient)
+	})
+
+	t.Run("decline", func(t *testing.T) {
+		sender, recipient := ts.newUser(t), ts.newUser(t)
+		request := send(t, sender, recipient)
+
+		if resp := respond(t, recipient, request.ID, "DECLINE"); len(resp.Errors) > 0 {
+			t.Fatalf("decline failed: %+v", resp.Errors)
+		}
+		sender.expectError(t, "already declined", cancel, map[string]interface{}{"id": request.ID})
+
+		// A declined request doesn't stop a new one
+		send(t, sender, recipient)
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		sender, recipient := ts.newUser(t), ts.newUser(t)
+		request := send(t, sender, recipient)
+
+		// Only the sender cancels
+		recipient.expectError(t, "not found", cancel, map[string]interface{}{"id": request.ID})
+
+		var cancelled struct{ CancelFriendRequest friendRequest }
+		sender.mustDo(t, cancel, map[string]interface{}{"id": request.ID}, &cancelled)
+		if cancelled.CancelFriendRequest.Status != "CANCELLED" {
+			t.Errorf("status = %s, want CANCELLED", cancelled.CancelFriendRequest.Status)
+		}
+		if resp := respond(t, recipient, request.ID, "ACCEPT"); len(resp.Errors) == 0 || !strings.Contains(resp.Errors[0].Message, "already cancelled") {
+			t.Errorf("accepting a cancelled request: %+v", resp.Errors)
+		}
+
+		var outgoing struct {
+			OutgoingFriendRequests struct {
+				Edges []struct{ Node struct{ ID string } }
+			}
+		}
+		sender.mustDo(t, `{ outgoingFriendRequests { edges { node { id } } } }`, nil, &outgoing)
+		if n := len(outgoing.OutgoingFriendRequests.Edges); n != 0 {
+			t.Errorf("%d outgoing requests after cancelling", n)
+		}
+	})
+
+	t.Run("hidden participant", func(t *testing.T) {
+		sender, recipient := ts.newUser(t), ts.newUser(t)
+		request := send(t, sender, recipient)
+		sender.mustDo(t, `mutation { deleteAccount { scheduledFor } }`, nil, nil)
+
+		var incoming struct {
+			IncomingFriendRequests struct {
+				Edges []struct{ Node friendRequest }
+			}
+		}
+		recipient.mustDo(t, `{ incomingFriendRequests { edges { node { `+fields+` } } } }`, nil, &incoming)
+		for _, edge := range incoming.IncomingFriendRequests.Edges {
+			if edge.Node.ID == request.ID && edge.Node.Sender != nil {
+				t.Error("sender pending deletion is still resolved")
+			}
+		}
+	})
+}
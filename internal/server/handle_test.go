@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHandleChangeCooldown(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.newUser(t)
+	base := "h" + strings.ReplaceAll(c.id, "-", "")[:20]
+
+	// Only one of several concurrent changes gets through; the others hit the cooldown
+	const attempts = 5
+	var wg sync.WaitGroup
+	succeeded := make([]bool, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := c.post(t, `mutation($handle: String!) { setHandle(handle: $handle) { id } }`,
+				map[string]interface{}{"handle": fmt.Sprintf("%s_%d", base, i)})
+			var resp gqlResponse
+			if rec.Code == http.StatusOK && json.Unmarshal(rec.Body.Bytes(), &resp) == nil {
+				succeeded[i] = len(resp.Errors) == 0
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	changed := 0
+	for _, ok := range succeeded {
+		if ok {
+			changed++
+		}
+	}
+	if changed != 1 {
+		t.Fatalf("%d concurrent handle changes succeeded, want 1", changed)
+	}
+
+	var redirects int
+	if err := ts.db.QueryRow(`SELECT COUNT(*) FROM handle_redirects WHERE user_id = $1`, c.id).Scan(&redirects); err != nil {
+		t.Fatal(err)
+	}
+	if redirects != 0 {
+		t.Errorf("%d redirects recorded for a first handle", redirects)
+	}
+
+	c.expectError(t, "can be changed again after", `mutation($handle: String!) { setHandle(handle: $handle) { id } }`,
+		map[string]interface{}{"handle": base + "_new"})
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
+)
+
+// testDBNameEnv names the Postgres database the end-to-end tests run
+// against, reached with the usual DB_* settings. The tests are skipped when
+// it isn't set; the test workflow in .github/workflows sets it. Every test creates its own users, so the database can be
+// reused between runs, but it should not hold data anyone cares about.
+const testDBNameEnv = "TEST_DB_NAME"
+
+// testServer is a server wired to the test database, a FakeAuthenticator
+// and a fake Kratos admin API
+type testServer struct {
+	handler http.Handler
+	authn   *auth.FakeAuthenticator
+	kratos  *fakeKratos
+	db      *sql.DB
+}
+
+var (
+	sharedServer    *testServer
+	sharedServerErr error
+	sharedOnce      sync.Once
+)
+
+// newTestServer returns the server shared by the end-to-end tests, skipping
+// the test when no test database is configured. The schema is only
+// initialised once per run.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	name := os.Getenv(testDBNameEnv)
+	if name == "" {
+		t.Skipf("%s is not set", testDBNameEnv)
+	}
+
+	sharedOnce.Do(func() {
+		kratos := newFakeKratos()
+
+		cfg := config.LoadConfig()
+		cfg.DBName = name
+		cfg.KratosAdminURL = kratos.server.URL
+		cfg.IdentitySyncInterval = 0
+		cfg.AccountPurgeInterval = 0
+		cfg.StorageDriver = "local"
+		cfg.MediaSigningKey = "test-signing-key"
+		cfg.StorageLocalDir, sharedServerErr = os.MkdirTemp("", "travel-social-media")
+		if sharedServerErr != nil {
+			return
+		}
+
+		authn := auth.NewFakeAuthenticator()
+		s, err := New(cfg, WithAuthenticator(authn))
+		if err != nil {
+			sharedServerErr = err
+			return
+		}
+
+		database, err := sql.Open("postgres", cfg.GetDBConnString())
+		if err != nil {
+			sharedServerErr = err
+			return
+		}
+
+		sharedServer = &testServer{
+			handler: s.Handler(),
+			authn:   authn,
+			kratos:  kratos,
+			db:      database,
+		}
+	})
+
+	if sharedServerErr != nil {
+		t.Fatalf("failed to start test server: %v", sharedServerErr)
+	}
+	return sharedServer
+}
+
+// newID returns a random UUID, the format Kratos identity IDs have
+func newID(t *testing.T) string {
+	t.Helper()
+
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		t.Fatal(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// client calls the GraphQL API as one user
+type client struct {
+	ts    *testServer
+	id    string
+	token string
+}
+
+// newUser signs up a new identity, mints a session for it and provisions its
+// users row
+func (ts *testServer) newUser(t *testing.T) *client {
+	t.Helper()
+
+	id := newID(t)
+	token, _ := ts.authn.MintSession(id, map[string]interface{}{
+		"email": id + "@example.com",
+		"name":  map[string]interface{}{"first": "Test", "last": id[:8]},
+	})
+
+	c := &client{ts: ts, id: id, token: token}
+	c.mustDo(t, `{ me { id } }`, nil, nil)
+	return c
+}
+
+// newUserWithRole creates a user holding role
+func (ts *testServer) newUserWithRole(t *testing.T, role auth.Role) *client {
+	t.Helper()
+
+	c := ts.newUser(t)
+	if _, err := ts.db.Exec(`UPDATE users SET role = $2 WHERE id = $1`, c.id, string(role)); err != nil {
+		t.Fatalf("failed to set role: %v", err)
+	}
+	return c
+}
+
+// withToken returns a client for the same user authenticating with another
+// token, e.g. a personal access token
+func (c *client) withToken(token string) *client {
+	return &client{ts: c.ts, id: c.id, token: token}
+}
+
+// visible reports whether viewer can look up the user with the given ID
+func visible(t *testing.T, viewer *client, id string) bool {
+	t.Helper()
+
+	var out struct{ User *struct{ ID string } }
+	viewer.do(t, `query($id: ID!) { user(id: $id) { id } }`, map[string]interface{}{"id": id}, &out)
+	return out.User != nil
+}
+
+// gqlError is an error in a GraphQL response
+type gqlError struct {
+	Message    string                 `json:"message"`
+	Extensions map[string]interface{} `json:"extensions"`
+}
+
+// gqlResponse is the body of a GraphQL response
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []gqlError      `json:"errors"`
+}
+
+// code returns the code of the first error, or "" without errors
+func (r *gqlResponse) code() string {
+	if len(r.Errors) == 0 {
+		return ""
+	}
+	code, _ := r.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+// post sends a GraphQL request and returns the raw HTTP response
+func (c *client) post(t *testing.T, query string, vars map[string]interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": vars})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ts.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// do sends a GraphQL request, decoding its data into out when out isn't nil
+func (c *client) do(t *testing.T, query string, vars map[string]interface{}, out interface{}) *gqlResponse {
+	t.Helper()
+
+	rec := c.post(t, query, vars)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, strings.TrimSpace(rec.Body.String()))
+	}
+
+	var resp gqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if out != nil && len(resp.Data) > 0 && string(resp.Data) != "null" {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			t.Fatalf("failed to decode data: %v", err)
+		}
+	}
+	return &resp
+}
+
+// mustDo is do for requests that must succeed
+func (c *client) mustDo(t *testing.T, query string, vars map[string]interface{}, out interface{}) {
+	t.Helper()
+
+	if resp := c.do(t, query, vars, out); len(resp.Errors) > 0 {
+		t.Fatalf("request failed: %+v", resp.Errors)
+	}
+}
+
+// expectCode sends a request that must fail with the given error code
+func (c *client) expectCode(t *testing.T, code, query string, vars map[string]interface{}) {
+	t.Helper()
+
+	resp := c.do(t, query, vars, nil)
+	if got := resp.code(); got != code {
+		t.Fatalf("error code = %q, want %q (errors: %+v)", got, code, resp.Errors)
+	}
+}
+
+// expectError sends a request that must fail with a message containing substr
+func (c *client) expectError(t *testing.T, substr, query string, vars map[string]interface{}) {
+	t.Helper()
+
+	resp := c.do(t, query, vars, nil)
+	for _, e := range resp.Errors {
+		if strings.Contains(e.Message, substr) {
+			return
+		}
+	}
+	t.Fatalf("expected an error containing %q, got %+v", substr, resp.Errors)
+}
+
+// fakeKratos serves the identity state updates of the Kratos admin API
+type fakeKratos struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	states map[string]string
+	fail   map[string]bool
+}
+
+func newFakeKratos() *fakeKratos {
+	k := &fakeKratos{
+		states: make(map[string]string),
+		fail:   make(map[string]bool),
+	}
+	k.server = httptest.NewServer(http.HandlerFunc(k.serveHTTP))
+	return k
+}
+
+func (k *fakeKratos) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/admin/identities/")
+	if r.Method != http.MethodPatch || id == r.URL.Path {
+		http.NotFound(w, r)
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.fail[id] {
+		http.Error(w, `{"error": {"code": 500, "message": "unavailable"}}`, http.StatusInternalServerError)
+		return
+	}
+
+	var patches []struct {
+		Path  string `json:"path"`
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&patches); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, patch := range patches {
+		if patch.Path == "/state" {
+			k.states[id] = patch.Value
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         id,
+		"schema_id":  "default",
+		"schema_url": k.server.URL + "/schemas/default",
+		"traits":     map[string]interface{}{},
+		"state":      k.states[id],
+	})
+}
+
+// state returns the last state set on the identity
+func (k *fakeKratos) state(id string) string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.states[id]
+}
+
+// setFailing makes updates to the identity fail, or succeed again
+func (k *fakeKratos) setFailing(id string, failing bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.fail[id] = failing
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// job is a background task the server runs on a fixed interval
+type job struct {
+	name     string
+	interval time.Duration
+	run      func(ctx context.Context) error
+}
+
+// start runs the job every interval until ctx is cancelled
+func (j job) start(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.run(ctx); err != nil {
+				log.Printf("Job %s failed: %v", j.name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/storage"
+)
+
+// mediaHandler serves blobs from the store to holders of a URL issued by signer
+func mediaHandler(store storage.BlobStore, signer *storage.URLSigner) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := chi.URLParam(r, "*")
+		if err := signer.Verify(key, r.URL.Query()); err != nil {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		body, contentType, err := store.Get(r.Context(), key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				http.NotFound(w, r)
+				return
+			}
+			log.Printf("Failed to read blob %s: %v", key, err)
+			http.Error(w, "Failed to read media", http.StatusInternalServerError)
+			return
+		}
+		defer body.Close()
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(signer.TTL().Seconds())))
+		if _, err := io.Copy(w, body); err != nil {
+			log.Printf("Failed to send blob %s: %v", key, err)
+		}
+	}
+}
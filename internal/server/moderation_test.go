@@ -0,0 +1,174 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+)
+
+// report is the part of a Report the tests look at
+type report struct {
+	ID             string
+	ReportedUserID *string
+	Status         string
+	Action         *string
+	SuspensionID   *string
+}
+
+const reportFields = `id reportedUserId status action suspensionId`
+
+// reportAndFind files a report by reporter about target and returns it as
+// the admin sees it
+func reportAndFind(t *testing.T, admin, reporter, target *client) report {
+	t.Helper()
+
+	reporter.mustDo(t, `mutation($id: ID!) { reportUser(input: {userId: $id, category: SPAM}) }`,
+		map[string]interface{}{"id": target.id}, nil)
+
+	var out struct {
+		Reports struct{ Edges []struct{ Node report } }
+	}
+	admin.mustDo(t, `query($id: ID!) { reports(userId: $id) { edges { node { `+reportFields+` } } } }`,
+		map[string]interface{}{"id": target.id}, &out)
+	if n := len(out.Reports.Edges); n != 1 {
+		t.Fatalf("found %d reports about the user, want 1", n)
+	}
+	return out.Reports.Edges[0].Node
+}
+
+// suspensions counts the suspensions a user has received
+func (ts *testServer) suspensions(t *testing.T, userID string) int {
+	t.Helper()
+
+	var n int
+	if err := ts.db.QueryRow(`SELECT COUNT(*) FROM user_suspensions WHERE user_id = $1`, userID).Scan(&n); err != nil {
+		t.Fatalf("failed to count suspensions: %v", err)
+	}
+	return n
+}
+
+func TestReportResolution(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.newUserWithRole(t, auth.RoleAdmin)
+	moderator := ts.newUserWithRole(t, auth.RoleModerator)
+
+	resolve := `mutation($id: ID!, $action: ModerationAction!) {
+		resolveReport(input: {reportId: $id, action: $action, note: "spam"}) { ` + reportFields + ` }
+	}`
+
+	t.Run("suspend", func(t *testing.T) {
+		reporter, offender := ts.newUser(t), ts.newUser(t)
+		filed := reportAndFind(t, admin, reporter, offender)
+		if filed.Status != "OPEN" || filed.ReportedUserID == nil || *filed.ReportedUserID != offender.id {
+			t.Fatalf("unexpected report %+v", filed)
+		}
+
+		reporter.expectError(t, "already reported", `mutation($id: ID!) { reportUser(input: {userId: $id, category: SPAM}) }`,
+			map[string]interface{}{"id": offender.id})
+
+		// Only admins handle reports
+		vars := map[string]interface{}{"id": filed.ID, "action": "SUSPEND"}
+		reporter.expectCode(t, auth.CodeForbidden, `{ reports { edges { node { id } } } }`, nil)
+		moderator.expectCode(t, auth.CodeForbidden, `{ reports { edges { node { id } } } }`, nil)
+		moderator.expectCode(t, auth.CodeForbidden, resolve, vars)
+
+		var resolved struct{ ResolveReport report }
+		admin.mustDo(t, resolve, vars, &resolved)
+		if resolved.ResolveReport.Status != "ACTIONED" || resolved.ResolveReport.SuspensionID == nil {
+			t.Errorf("unexpected resolution %+v", resolved.ResolveReport)
+		}
+		offender.expectCode(t, auth.CodeAccountSuspended, `{ me { id } }`, nil)
+		if n := ts.suspensions(t, offender.id); n != 1 {
+			t.Errorf("%d suspensions recorded, want 1", n)
+		}
+
+		admin.expectError(t, "already actioned", resolve, map[string]interface{}{"id": filed.ID, "action": "DISMISS"})
+	})
+
+	t.Run("dismiss", func(t *testing.T) {
+		reporter, target := ts.newUser(t), ts.newUser(t)
+		filed := reportAndFind(t, admin, reporter, target)
+
+		var resolved struct{ ResolveReport report }
+		admin.mustDo(t, resolve, map[string]interface{}{"id": filed.ID, "action": "DISMISS"}, &resolved)
+		if resolved.ResolveReport.Status != "DISMISSED" || resolved.ResolveReport.SuspensionID != nil {
+			t.Errorf("unexpected resolution %+v", resolved.ResolveReport)
+		}
+		target.mustDo(t, `{ me { id } }`, nil, nil)
+	})
+
+	t.Run("remove content", func(t *testing.T) {
+		reporter, target := ts.newUser(t), ts.newUser(t)
+		target.mustDo(t, `mutation { updateProfile(input: {bio: "Buy followers now", interests: ["spam"]}) { id } }`, nil, nil)
+		filed := reportAndFind(t, admin, reporter, target)
+
+		var resolved struct{ ResolveReport report }
+		admin.mustDo(t, resolve, map[string]interface{}{"id": filed.ID, "action": "REMOVE_CONTENT"}, &resolved)
+		if resolved.ResolveReport.Status != "ACTIONED" || resolved.ResolveReport.SuspensionID != nil {
+			t.Errorf("unexpected resolution %+v", resolved.ResolveReport)
+		}
+
+		var me struct {
+			Me struct {
+				Bio       *string
+				Interests []string
+			}
+		}
+		target.mustDo(t, `{ me { bio interests } }`, nil, &me)
+		if me.Me.Bio != nil || len(me.Me.Interests) != 0 {
+			t.Errorf("profile content survived: %+v", me.Me)
+		}
+	})
+
+	t.Run("failed action", func(t *testing.T) {
+		reporter := ts.newUser(t)
+		peer := ts.newUserWithRole(t, auth.RoleAdmin)
+		filed := reportAndFind(t, admin, reporter, peer)
+
+		// Admins can't suspend other admins, and the report stays open
+		admin.expectCode(t, auth.CodeForbidden, resolve, map[string]interface{}{"id": filed.ID, "action": "SUSPEND"})
+		if n := ts.suspensions(t, peer.id); n != 0 {
+			t.Errorf("%d suspensions recorded for a failed action", n)
+		}
+
+		var out struct{ Report report }
+		admin.mustDo(t, `query($id: ID!) { report(id: $id) { `+reportFields+` } }`, map[string]interface{}{"id": filed.ID}, &out)
+		if out.Report.Status != "OPEN" {
+			t.Errorf("status = %s after a failed action, want OPEN", out.Report.Status)
+		}
+	})
+
+	t.Run("deleted account", func(t *testing.T) {
+		reporter, target := ts.newUser(t), ts.newUser(t)
+		filed := reportAndFind(t, admin, reporter, target)
+
+		if _, err := ts.db.Exec(`DELETE FROM users WHERE id = $1`, target.id); err != nil {
+			t.Fatalf("failed to delete user: %v", err)
+		}
+
+		var out struct{ Report *report }
+		admin.mustDo(t, `query($id: ID!) { report(id: $id) { `+reportFields+` } }`, map[string]interface{}{"id": filed.ID}, &out)
+		if out.Report == nil {
+			t.Fatal("report was deleted with the account")
+		}
+		if out.Report.ReportedUserID != nil {
+			t.Errorf("reportedUserId = %s, want null", *out.Report.ReportedUserID)
+		}
+
+		admin.expectError(t, "can only be dismissed", resolve, map[string]interface{}{"id": filed.ID, "action": "SUSPEND"})
+		admin.mustDo(t, resolve, map[string]interface{}{"id": filed.ID, "action": "DISMISS"}, nil)
+	})
+}
+
+func TestReportingRequiresVisibility(t *testing.T) {
+	ts := newTestServer(t)
+	reporter := ts.newUser(t)
+	target := ts.newUser(t)
+
+	reporter.mustDo(t, `mutation($id: ID!) { blockUser(userId: $id) }`, map[string]interface{}{"id": target.id}, nil)
+
+	report := `mutation($id: ID!) { reportUser(input: {userId: $id, category: SPAM}) }`
+	reporter.expectError(t, "not found", report, map[string]interface{}{"id": target.id})
+	target.expectError(t, "not found", report, map[string]interface{}{"id": reporter.id})
+	reporter.expectError(t, "yourself", report, map[string]interface{}{"id": reporter.id})
+}
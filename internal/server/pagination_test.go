@@ -0,0 +1,82 @@
+package server
+
+import "testing"
+
+// TestConnectionCursorsRoundTrip pages through keyset and in-memory
+// connections and checks that every item is returned exactly once
+func TestConnectionCursorsRoundTrip(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.newUser(t)
+
+	const tokens = 5
+	for i := 0; i < tokens; i++ {
+		owner.mustDo(t, `mutation { createPersonalAccessToken(input: {name: "t", scopes: ["read:profile"]}) { token } }`, nil, nil)
+	}
+	for i := 0; i < 2; i++ {
+		ts.authn.MintSession(owner.id, map[string]interface{}{"email": owner.id + "@example.com"})
+	}
+
+	type page struct {
+		Edges []struct {
+			Node   struct{ ID string }
+			Cursor string
+		}
+		PageInfo struct {
+			HasNextPage     bool
+			HasPreviousPage bool
+			EndCursor       *string
+		}
+	}
+
+	collect := func(t *testing.T, query, field string) []string {
+		t.Helper()
+
+		var ids []string
+		var after *string
+		for pages := 0; ; pages++ {
+			if pages > 10 {
+				t.Fatal("pagination didn't terminate")
+			}
+
+			var out map[string]page
+			owner.mustDo(t, query, map[string]interface{}{"after": after}, &out)
+			p := out[field]
+			if p.PageInfo.HasPreviousPage != (after != nil) {
+				t.Errorf("page %d: hasPreviousPage = %v", pages, p.PageInfo.HasPreviousPage)
+			}
+			for _, edge := range p.Edges {
+				ids = append(ids, edge.Node.ID)
+			}
+			if !p.PageInfo.HasNextPage {
+				return ids
+			}
+			after = p.PageInfo.EndCursor
+		}
+	}
+
+	tokenIDs := collect(t, `query($after: String) {
+		personalAccessTokens(first: 2, after: $after) { edges { node { id } cursor } pageInfo { hasNextPage hasPreviousPage endCursor } }
+	}`, "personalAccessTokens")
+	sessionIDs := collect(t, `query($after: String) {
+		mySessions(first: 2, after: $after) { edges { node { id } cursor } pageInfo { hasNextPage hasPreviousPage endCursor } }
+	}`, "mySessions")
+
+	for name, ids := range map[string][]string{"tokens": tokenIDs, "sessions": sessionIDs} {
+		want := tokens
+		if name == "sessions" {
+			want = 3
+		}
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			if seen[id] {
+				t.Errorf("%s: %s returned twice", name, id)
+			}
+			seen[id] = true
+		}
+		if len(seen) != want {
+			t.Errorf("%s: got %d items, want %d", name, len(seen), want)
+		}
+	}
+
+	owner.expectError(t, "invalid cursor", `{ personalAccessTokens(after: "tampered") { edges { cursor } } }`, nil)
+}
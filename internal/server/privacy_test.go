@@ -0,0 +1,109 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFollowersVisibility(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.newUser(t)
+	follower := ts.newUser(t)
+	stranger := ts.newUser(t)
+
+	owner.mustDo(t, `mutation {
+		updateProfile(input: {bio: "Backpacking through Asia"}) { id }
+		updatePrivacySettings(input: {bio: FOLLOWERS, lastName: ONLY_ME}) { bio }
+	}`, nil, nil)
+	follower.mustDo(t, `mutation($id: ID!) { follow(userId: $id) { id } }`, map[string]interface{}{"id": owner.id}, nil)
+
+	type profile struct {
+		User *struct {
+			Bio      *string
+			LastName *string
+		}
+	}
+	look := func(t *testing.T, viewer *client) profile {
+		t.Helper()
+		var out profile
+		viewer.mustDo(t, `query($id: ID!) { user(id: $id) { bio lastName } }`, map[string]interface{}{"id": owner.id}, &out)
+		if out.User == nil {
+			t.Fatal("owner is not visible")
+		}
+		return out
+	}
+
+	if p := look(t, owner); p.User.Bio == nil || p.User.LastName == nil {
+		t.Errorf("owner can't see their own fields: %+v", p.User)
+	}
+	if p := look(t, follower); p.User.Bio == nil || *p.User.Bio != "Backpacking through Asia" {
+		t.Errorf("follower can't see a FOLLOWERS field: %+v", p.User)
+	} else if p.User.LastName != nil {
+		t.Error("follower can see an ONLY_ME field")
+	}
+	if p := look(t, stranger); p.User.Bio != nil || p.User.LastName != nil {
+		t.Errorf("stranger can see private fields: %+v", p.User)
+	}
+
+	follower.mustDo(t, `mutation($id: ID!) { unfollow(userId: $id) }`, map[string]interface{}{"id": owner.id}, nil)
+	if p := look(t, follower); p.User.Bio != nil {
+		t.Error("former follower can still see a FOLLOWERS field")
+	}
+}
+
+func TestDefaultPrivacySettingsAreStored(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.newUser(t)
+	stranger := ts.newUser(t)
+
+	// Provisioning stores the defaults, so nothing has to fall back to them
+	var email, lastName, bio, interests, travelPreferences string
+	err := ts.db.QueryRow(`
+		SELECT email, last_name, bio, interests, travel_preferences
+		FROM privacy_settings WHERE user_id = $1`, owner.id).
+		Scan(&email, &lastName, &bio, &interests, &travelPreferences)
+	if err != nil {
+		t.Fatalf("no privacy settings stored for a new user: %v", err)
+	}
+
+	var out struct {
+		MyPrivacySettings struct {
+			Email, LastName, Bio, Interests, TravelPreferences string
+		}
+	}
+	owner.mustDo(t, `{ myPrivacySettings { email lastName bio interests travelPreferences } }`, nil, &out)
+	got := out.MyPrivacySettings
+	stored := []string{email, lastName, bio, interests, travelPreferences}
+	want := []string{"ONLY_ME", "PUBLIC", "PUBLIC", "PUBLIC", "PUBLIC"}
+	for i, v := range []string{got.Email, got.LastName, got.Bio, got.Interests, got.TravelPreferences} {
+		if v != want[i] || strings.ToUpper(stored[i]) != want[i] {
+			t.Errorf("setting %d: returned %s, stored %s, want %s", i, v, stored[i], want[i])
+		}
+	}
+
+	// Search indexes the last name under the stored default and drops it once hidden
+	lastNameHits := func(t *testing.T) int {
+		t.Helper()
+		var out struct {
+			SearchUsers struct {
+				Edges []struct{ Node struct{ ID string } }
+			}
+		}
+		stranger.mustDo(t, `query($q: String!) { searchUsers(query: $q) { edges { node { id } } } }`,
+			map[string]interface{}{"q": owner.id[:8]}, &out)
+		hits := 0
+		for _, edge := range out.SearchUsers.Edges {
+			if edge.Node.ID == owner.id {
+				hits++
+			}
+		}
+		return hits
+	}
+	if n := lastNameHits(t); n != 1 {
+		t.Errorf("user found by their public last name %d times, want 1", n)
+	}
+	owner.mustDo(t, `mutation { updatePrivacySettings(input: {lastName: ONLY_ME}) { lastName } }`, nil, nil)
+	if n := lastNameHits(t); n != 0 {
+		t.Errorf("user found by their hidden last name %d times", n)
+	}
+}
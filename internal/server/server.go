@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,6 +16,10 @@ import (
 	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/graph"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/generated"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/moderation"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/social"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/storage"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/token"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
 )
 
@@ -22,19 +27,80 @@ import (
 type Server struct {
 	router chi.Router
 	config *config.Config
+	jobs   []job
+}
+
+// Option customises the dependencies New wires into the server
+type Option func(*options)
+
+type options struct {
+	authenticator auth.Authenticator
+}
+
+// WithAuthenticator replaces the default cached Kratos authenticator, e.g.
+// with an auth.FakeAuthenticator so integration tests only need Postgres
+func WithAuthenticator(authn auth.Authenticator) Option {
+	return func(o *options) {
+		o.authenticator = authn
+	}
 }
 
 // New creates a new server instance
-func New(cfg *config.Config) (*Server, error) {
+func New(cfg *config.Config, opts ...Option) (*Server, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	// Connect to database
 	database, err := db.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
+	// Set up media storage
+	if cfg.MediaSigningKey == "" {
+		return nil, fmt.Errorf("MEDIA_SIGNING_KEY is required")
+	}
+	mediaStore, err := storage.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize media storage: %w", err)
+	}
+	mediaSigner := storage.NewURLSigner(cfg.MediaSigningKey, cfg.PublicBaseURL, cfg.MediaURLTTL)
+
+	// Suggestion scores divide by the radius
+	if cfg.SuggestionRadiusKm <= 0 {
+		return nil, fmt.Errorf("SUGGESTION_RADIUS_KM must be greater than 0")
+	}
+
 	// Set up repositories and services
 	userRepo := user.NewRepository(database)
-	userService := user.NewService(userRepo, cfg)
+	userService := user.NewService(userRepo, cfg, mediaStore)
+	tokenRepo := token.NewRepository(database)
+	tokenService := token.NewService(tokenRepo)
+	socialRepo := social.NewRepository(database)
+	socialService := social.NewService(socialRepo, cfg, userService)
+	moderationRepo := moderation.NewRepository(database)
+	moderationService := moderation.NewService(moderationRepo, userService)
+
+	// Users provisioned before privacy settings were stored with them get the defaults
+	provisioned, err := userService.ProvisionPrivacySettings(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to provision privacy settings: %w", err)
+	}
+	if provisioned > 0 {
+		log.Printf("Stored default privacy settings for %d users", provisioned)
+	}
+
+	// Set up authentication
+	authn := o.authenticator
+	if authn == nil {
+		sessionCache, err := auth.NewSessionCache(cfg.SessionCacheSize, cfg.SessionCacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize session cache: %w", err)
+		}
+		authn = auth.NewCachingAuthenticator(auth.NewKratosAuthenticator(cfg), sessionCache)
+	}
 
 	// Set up router
 	r := chi.NewRouter()
@@ -45,14 +111,25 @@ func New(cfg *config.Config) (*Server, error) {
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.Timeout(60 * time.Second))
-	r.Use(auth.Middleware(cfg))
+	r.Use(auth.Middleware(authn, tokenService, userService))
 
 	// Set up GraphQL handler
 	resolver := &graph.Resolver{
-		UserService: userService,
+		Config:            cfg,
+		Authenticator:     authn,
+		UserService:       userService,
+		TokenService:      tokenService,
+		SocialService:     socialService,
+		ModerationService: moderationService,
+		MediaSigner:       mediaSigner,
 	}
 
-	gqlServer := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))
+	gqlServer := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{
+		Resolvers:  resolver,
+		Directives: graph.Directives(cfg, userService, socialService),
+	}))
+	gqlServer.SetErrorPresenter(graph.ErrorPresenter)
+	gqlServer.AroundOperations(graph.PrivacyCache)
 
 	// Routes
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -61,18 +138,71 @@ func New(cfg *config.Config) (*Server, error) {
 
 	r.Handle("/playground", playground.Handler("GraphQL playground", "/query"))
 	r.Handle("/query", gqlServer)
+	r.Post("/logout", auth.LogoutHandler(authn))
+	r.Get(storage.MediaPath+"*", mediaHandler(mediaStore, mediaSigner))
+
+	// Kratos web_hook actions
+	r.Route("/webhooks/kratos", func(r chi.Router) {
+		r.Use(requireWebhookSecret(cfg.KratosWebhookSecret))
+		r.Post("/registration", registrationWebhook(userService))
+		r.Post("/settings", settingsWebhook(userService))
+	})
+
+	// Background jobs
+	var jobs []job
+	if cfg.IdentitySyncInterval > 0 {
+		jobs = append(jobs, job{
+			name:     "identity-sync",
+			interval: cfg.IdentitySyncInterval,
+			run: func(ctx context.Context) error {
+				report, err := userService.ReconcileIdentities(ctx)
+				if err != nil {
+					return err
+				}
+				log.Printf("Identity sync finished: %s", report)
+				return nil
+			},
+		})
+	}
+
+	if cfg.AccountPurgeInterval > 0 {
+		jobs = append(jobs, job{
+			name:     "account-purge",
+			interval: cfg.AccountPurgeInterval,
+			run: func(ctx context.Context) error {
+				purged, err := userService.PurgeDeletedAccounts(ctx)
+				if err != nil {
+					return err
+				}
+				if purged > 0 {
+					log.Printf("Purged %d deleted accounts", purged)
+				}
+				return nil
+			},
+		})
+	}
 
 	return &Server{
 		router: r,
 		config: cfg,
+		jobs:   jobs,
 	}, nil
 }
 
+// Handler returns the server's root HTTP handler
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	port := s.config.AppPort
 	addr := fmt.Sprintf(":%s", port)
 
+	for _, j := range s.jobs {
+		go j.start(context.Background())
+	}
+
 	log.Printf("Server is running on http://localhost:%s", port)
 	log.Printf("GraphQL playground available at http://localhost:%s/playground", port)
 
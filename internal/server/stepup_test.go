@@ -0,0 +1,33 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+)
+
+func TestStepUpDirective(t *testing.T) {
+	ts := newTestServer(t)
+	id := newID(t)
+	token, session := ts.authn.MintSession(id, map[string]interface{}{"email": id + "@example.com"})
+	c := &client{ts: ts, id: id, token: token}
+	c.mustDo(t, `{ me { id } }`, nil, nil)
+
+	create := `mutation { createPersonalAccessToken(input: {name: "ci", scopes: ["read:profile"]}) { token } }`
+
+	// Sessions authenticated longer ago than the recent-auth window must log in again
+	stale := time.Now().Add(-24 * time.Hour)
+	session.AuthenticatedAt = &stale
+	resp := c.do(t, create, nil, nil)
+	if resp.code() != auth.CodeStepUpRequired {
+		t.Fatalf("error code = %q, want %q", resp.code(), auth.CodeStepUpRequired)
+	}
+	if flow, _ := resp.Errors[0].Extensions["loginFlow"].(string); flow == "" {
+		t.Errorf("step-up error without a login flow: %+v", resp.Errors[0])
+	}
+
+	fresh := time.Now()
+	session.AuthenticatedAt = &fresh
+	c.mustDo(t, create, nil, nil)
+}
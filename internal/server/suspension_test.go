@@ -0,0 +1,56 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+)
+
+func TestSuspendedUsersAreLockedOut(t *testing.T) {
+	ts := newTestServer(t)
+	moderator := ts.newUserWithRole(t, auth.RoleModerator)
+	offender := ts.newUser(t)
+	other := ts.newUser(t)
+
+	var suspended struct {
+		SuspendUser struct {
+			ID     string
+			Active bool
+		}
+	}
+	moderator.mustDo(t, `mutation($id: ID!) { suspendUser(input: {userId: $id, reason: "spam", durationHours: 24}) { id active } }`,
+		map[string]interface{}{"id": offender.id}, &suspended)
+	if !suspended.SuspendUser.Active {
+		t.Fatal("suspension is not active")
+	}
+
+	resp := offender.do(t, `{ me { id } }`, nil, nil)
+	if resp.code() != auth.CodeAccountSuspended {
+		t.Fatalf("error code = %q, want %q", resp.code(), auth.CodeAccountSuspended)
+	}
+	if reason := resp.Errors[0].Extensions["reason"]; reason != "spam" {
+		t.Errorf("reason = %v, want spam", reason)
+	}
+	if _, ok := resp.Errors[0].Extensions["expiresAt"]; !ok {
+		t.Error("timed suspension without expiresAt")
+	}
+
+	// Suspended users are hidden from everyone else
+	var found struct{ User *struct{ ID string } }
+	other.do(t, `query($id: ID!) { user(id: $id) { id } }`, map[string]interface{}{"id": offender.id}, &found)
+	if found.User != nil {
+		t.Error("suspended user is still visible")
+	}
+
+	// Moderators can't suspend users of their own rank
+	peer := ts.newUserWithRole(t, auth.RoleModerator)
+	moderator.expectCode(t, auth.CodeForbidden, `mutation($id: ID!) { suspendUser(input: {userId: $id, reason: "x"}) { id } }`,
+		map[string]interface{}{"id": peer.id})
+
+	var lifted struct{ UnsuspendUser bool }
+	moderator.mustDo(t, `mutation($id: ID!) { unsuspendUser(userId: $id) }`, map[string]interface{}{"id": offender.id}, &lifted)
+	if !lifted.UnsuspendUser {
+		t.Error("unsuspendUser reported nothing to lift")
+	}
+	offender.mustDo(t, `{ me { id } }`, nil, nil)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+)
+
+func TestPersonalAccessTokenScopes(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.newUser(t)
+	other := ts.newUser(t)
+
+	var created struct {
+		CreatePersonalAccessToken struct {
+			Token               string
+			PersonalAccessToken struct {
+				ID     string
+				Scopes []string
+			}
+		}
+	}
+	owner.mustDo(t, `mutation { createPersonalAccessToken(input: {name: "reader", scopes: ["read:profile"]}) {
+		token personalAccessToken { id scopes }
+	} }`, nil, &created)
+	pat := owner.withToken(created.CreatePersonalAccessToken.Token)
+
+	var me struct{ Me struct{ ID string } }
+	pat.mustDo(t, `{ me { id } }`, nil, &me)
+	if me.Me.ID != owner.id {
+		t.Errorf("token authenticated %s, want %s", me.Me.ID, owner.id)
+	}
+
+	// Fields needing a scope the token wasn't granted
+	pat.expectCode(t, auth.CodeForbidden, `query($id: ID!) { user(id: $id) { id } }`, map[string]interface{}{"id": other.id})
+	pat.expectCode(t, auth.CodeForbidden, `mutation { updateProfile(input: {bio: "x"}) { id } }`, nil)
+	// Fields without a scope and role-gated fields are limited to interactive sessions
+	pat.expectCode(t, auth.CodeForbidden, `{ personalAccessTokens { edges { node { id } } } }`, nil)
+	pat.expectCode(t, auth.CodeForbidden, `mutation { createPersonalAccessToken(input: {name: "x", scopes: ["read:users"]}) { token } }`, nil)
+
+	owner.expectError(t, "unknown scope", `mutation { createPersonalAccessToken(input: {name: "x", scopes: ["admin"]}) { token } }`, nil)
+
+	var revoked struct{ RevokePersonalAccessToken bool }
+	owner.mustDo(t, `mutation($id: ID!) { revokePersonalAccessToken(id: $id) }`,
+		map[string]interface{}{"id": created.CreatePersonalAccessToken.PersonalAccessToken.ID}, &revoked)
+	if !revoked.RevokePersonalAccessToken {
+		t.Fatal("token was not revoked")
+	}
+	if rec := pat.post(t, `{ me { id } }`, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	unknown := owner.withToken(auth.PersonalAccessTokenPrefix + "does-not-exist")
+	if rec := unknown.post(t, `{ me { id } }`, nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+)
+
+// kratosWebhookHeader carries the shared secret configured for Kratos web_hook
+// actions in kratos/kratos.yml
+const kratosWebhookHeader = "X-Webhook-Secret"
+
+// kratosWebhookPayload is the request body rendered by
+// kratos/webhooks/identity.jsonnet
+type kratosWebhookPayload struct {
+	Identity struct {
+		ID     string      `json:"id"`
+		Traits interface{} `json:"traits"`
+	} `json:"identity"`
+}
+
+// requireWebhookSecret rejects requests that don't present the shared secret.
+// An empty secret disables the webhook endpoints entirely.
+func requireWebhookSecret(secret string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := r.Header.Get(kratosWebhookHeader)
+			if secret == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// decodeKratosWebhook parses the identity sent by a Kratos web_hook action
+func decodeKratosWebhook(w http.ResponseWriter, r *http.Request) (string, *user.IdentityTraits, bool) {
+	var payload kratosWebhookPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil || payload.Identity.ID == "" {
+		http.Error(w, "Invalid webhook payload", http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	traits, err := user.ParseTraits(payload.Identity.Traits)
+	if err != nil {
+		http.Error(w, "Invalid identity traits", http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	return payload.Identity.ID, traits, true
+}
+
+// registrationWebhook provisions the local users row after Kratos registration
+func registrationWebhook(userService *user.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identityID, traits, ok := decodeKratosWebhook(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := userService.ProvisionUser(r.Context(), identityID, traits); err != nil {
+			log.Printf("Failed to provision user %s: %v", identityID, err)
+			http.Error(w, "Failed to provision user", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// settingsWebhook mirrors traits changed in a Kratos settings flow
+func settingsWebhook(userService *user.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identityID, traits, ok := decodeKratosWebhook(w, r)
+		if !ok {
+			return
+		}
+
+		if _, err := userService.SyncIdentity(r.Context(), identityID, traits); err != nil {
+			log.Printf("Failed to sync user %s: %v", identityID, err)
+			http.Error(w, "Failed to sync user", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,69 @@
+package social
+
+import (
+	"context"
+	"errors"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// BlockUser blocks another user. Blocked users and their blocker can't see
+// each other anywhere, which the user repository enforces for every query
+// through user.VisibleToFilter, and any follows, friendship or pending friend
+// request between them end.
+func (s *Service) BlockUser(ctx context.Context, blockerID, blockedID string) (bool, error) {
+	if blockerID == blockedID {
+		return false, errors.New("you can't block yourself")
+	}
+
+	if err := s.repo.Block(ctx, blockerID, blockedID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnblockUser lifts a block, reporting whether there was one
+func (s *Service) UnblockUser(ctx context.Context, blockerID, blockedID string) (bool, error) {
+	return s.repo.Unblock(ctx, blockerID, blockedID)
+}
+
+// MuteUser mutes another user. Muted users drop out of the muter's searches
+// and suggestions but can still be looked up, followed and befriended, and
+// aren't told they were muted.
+func (s *Service) MuteUser(ctx context.Context, muterID, mutedID string) (bool, error) {
+	if muterID == mutedID {
+		return false, errors.New("you can't mute yourself")
+	}
+
+	if err := s.repo.Mute(ctx, muterID, mutedID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// UnmuteUser lifts a mute, reporting whether there was one
+func (s *Service) UnmuteUser(ctx context.Context, muterID, mutedID string) (bool, error) {
+	return s.repo.Unmute(ctx, muterID, mutedID)
+}
+
+// BlockedUsers returns a page of the users the user blocked, most recent first
+func (s *Service) BlockedUsers(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.User], error) {
+	ids, err := s.repo.ListBlocked(ctx, userID, args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The block itself hides these users from the user, so they are loaded
+	// as someone without blocks would see them
+	return s.loadUsers(ctx, "", ids)
+}
+
+// MutedUsers returns a page of the users the user muted, most recent first
+func (s *Service) MutedUsers(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.User], error) {
+	ids, err := s.repo.ListMuted(ctx, userID, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadUsers(ctx, userID, ids)
+}
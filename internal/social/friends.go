@@ -0,0 +1,91 @@
+package social
+
+import (
+	"context"
+	"errors"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+var (
+	// ErrFriendRequestPending is returned when a pending friend request
+	// between the two users already exists, in either direction
+	ErrFriendRequestPending = errors.New("a friend request between you is already pending")
+	// ErrAlreadyFriends is returned when sending a friend request to a friend
+	ErrAlreadyFriends = errors.New("you are already friends")
+)
+
+// SendFriendRequest sends a friend request to another visible user. There can
+// only be one pending request between two users, whoever sent it.
+func (s *Service) SendFriendRequest(ctx context.Context, senderID, recipientID string) (*models.FriendRequest, error) {
+	if senderID == recipientID {
+		return nil, errors.New("you can't send yourself a friend request")
+	}
+
+	if _, err := s.users.GetVisibleUserByID(ctx, senderID, recipientID); err != nil {
+		return nil, err
+	}
+
+	friends, err := s.repo.AreFriends(ctx, senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+	if friends {
+		return nil, ErrAlreadyFriends
+	}
+
+	pending, err := s.repo.GetPendingFriendRequest(ctx, senderID, recipientID)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil {
+		if pending.SenderID == recipientID {
+			return nil, errors.New("they have already sent you a friend request, respond to it instead")
+		}
+		return nil, ErrFriendRequestPending
+	}
+
+	return s.repo.CreateFriendRequest(ctx, senderID, recipientID)
+}
+
+// RespondToFriendRequest accepts or declines a pending friend request sent
+// to the user. Accepting it makes the two users friends.
+func (s *Service) RespondToFriendRequest(ctx context.Context, userID, requestID string, response models.FriendRequestResponse) (*models.FriendRequest, error) {
+	status := "declined"
+	if response == models.FriendRequestResponseAccept {
+		status = "accepted"
+	}
+
+	return s.repo.ResolveFriendRequest(ctx, requestID, "recipient_id", userID, status)
+}
+
+// CancelFriendRequest withdraws a pending friend request the user sent
+func (s *Service) CancelFriendRequest(ctx context.Context, userID, requestID string) (*models.FriendRequest, error) {
+	return s.repo.ResolveFriendRequest(ctx, requestID, "sender_id", userID, "cancelled")
+}
+
+// RemoveFriend ends a friendship for both users, reporting whether they were friends
+func (s *Service) RemoveFriend(ctx context.Context, userID, friendID string) (bool, error) {
+	return s.repo.RemoveFriendship(ctx, userID, friendID)
+}
+
+// Friends returns a page of the user's friends that the viewer can see, most
+// recent friendships first
+func (s *Service) Friends(ctx context.Context, viewerID, userID string, args db.PageArgs) (*db.Page[*models.User], error) {
+	ids, err := s.repo.ListFriends(ctx, viewerID, userID, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadUsers(ctx, viewerID, ids)
+}
+
+// IncomingFriendRequests returns a page of the pending friend requests sent to the user
+func (s *Service) IncomingFriendRequests(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.FriendRequest], error) {
+	return s.repo.ListIncomingFriendRequests(ctx, userID, args)
+}
+
+// OutgoingFriendRequests returns a page of the pending friend requests the user sent
+func (s *Service) OutgoingFriendRequests(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.FriendRequest], error) {
+	return s.repo.ListOutgoingFriendRequests(ctx, userID, args)
+}
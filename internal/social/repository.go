@@ -0,0 +1,746 @@
+package social
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/geo"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Follow records that followerID follows followeeID. Following someone
+// already followed is not an error.
+func (r *Repository) Follow(ctx context.Context, followerID, followeeID string) error {
+	query := `
+		INSERT INTO follows (follower_id, followee_id)
+		VALUES ($1, $2)
+		ON CONFLICT (follower_id, followee_id) DO NOTHING
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, followerID, followeeID); err != nil {
+		return fmt.Errorf("error following user: %w", err)
+	}
+	return nil
+}
+
+// Unfollow removes a follow, reporting whether there was one
+func (r *Repository) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2", followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("error unfollowing user: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error unfollowing user: %w", err)
+	}
+	return n > 0, nil
+}
+
+// IsFollowing reports whether followerID follows followeeID
+func (r *Repository) IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2)`
+
+	var following bool
+	if err := r.db.QueryRowContext(ctx, query, followerID, followeeID).Scan(&following); err != nil {
+		return false, fmt.Errorf("error querying follow: %w", err)
+	}
+	return following, nil
+}
+
+// CountFollowers returns how many users the viewer may see follow the user
+func (r *Repository) CountFollowers(ctx context.Context, viewerID, userID string) (int, error) {
+	return r.countFollows(ctx, "followee_id", "follower_id", viewerID, userID)
+}
+
+// CountFollowing returns how many users the viewer may see the user follows
+func (r *Repository) CountFollowing(ctx context.Context, viewerID, userID string) (int, error) {
+	return r.countFollows(ctx, "follower_id", "followee_id", viewerID, userID)
+}
+
+// ListFollowers returns a page of the IDs of the users following the user
+// that the viewer may see, most recent first
+func (r *Repository) ListFollowers(ctx context.Context, viewerID, userID string, args db.PageArgs) (*db.Page[string], error) {
+	return r.listFollows(ctx, "followee_id", "follower_id", viewerID, userID, args)
+}
+
+// ListFollowing returns a page of the IDs of the users the user follows that
+// the viewer may see, most recently followed first
+func (r *Repository) ListFollowing(ctx context.Context, viewerID, userID string, args db.PageArgs) (*db.Page[string], error) {
+	return r.listFollows(ctx, "follower_id", "followee_id", viewerID, userID, args)
+}
+
+// countFollows counts the follows whose match column is userID and whose
+// other column is a user the viewer may see
+func (r *Repository) countFollows(ctx context.Context, match, other, viewerID, userID string) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM follows
+		JOIN users ON users.id = follows.` + other + `
+		WHERE follows.` + match + ` = $1 AND ` + user.VisibleToFilter("$2") + `
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, userID, viewerID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting follows: %w", err)
+	}
+	return count, nil
+}
+
+// listFollows pages through the users the viewer may see in the other column
+// of the follows whose match column is userID
+func (r *Repository) listFollows(ctx context.Context, match, other, viewerID, userID string, args db.PageArgs) (*db.Page[string], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT follows.` + other + `, follows.created_at
+		FROM follows
+		JOIN users ON users.id = follows.` + other + `
+		WHERE follows.` + match + ` = $1 AND ` + user.VisibleToFilter("$5") + ` AND (
+			$2::timestamptz IS NULL OR
+			follows.created_at < $2 OR
+			(follows.created_at = $2 AND follows.` + other + ` > $3)
+		)
+		ORDER BY follows.created_at DESC, follows.` + other + `
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit+1, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing follows: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (string, []interface{}, error) {
+		var id string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			return "", nil, fmt.Errorf("error scanning follow row: %w", err)
+		}
+		return id, []interface{}{createdAt, id}, nil
+	})
+}
+
+// friendRequestColumns lists the friend_requests columns read by
+// scanFriendRequest, in order. They are qualified so that queries can join users.
+const friendRequestColumns = `friend_requests.id, friend_requests.sender_id, friend_requests.recipient_id,
+		friend_requests.status, friend_requests.created_at, friend_requests.resolved_at`
+
+// scanFriendRequest reads a friend_requests row selected with friendRequestColumns
+func scanFriendRequest(row db.RowScanner) (*models.FriendRequest, error) {
+	var request models.FriendRequest
+	var status string
+	var createdAt time.Time
+	var resolvedAt sql.NullTime
+
+	err := row.Scan(&request.ID, &request.SenderID, &request.RecipientID, &status, &createdAt, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	request.Status = models.FriendRequestStatus(strings.ToUpper(status))
+	request.CreatedAt = createdAt.Format(time.RFC3339)
+	if resolvedAt.Valid {
+		formatted := resolvedAt.Time.Format(time.RFC3339)
+		request.ResolvedAt = &formatted
+	}
+
+	return &request, nil
+}
+
+// CreateFriendRequest records a pending friend request
+func (r *Repository) CreateFriendRequest(ctx context.Context, senderID, recipientID string) (*models.FriendRequest, error) {
+	query := `
+		INSERT INTO friend_requests (id, sender_id, recipient_id)
+		VALUES (gen_random_uuid(), $1, $2)
+		RETURNING ` + friendRequestColumns + `
+	`
+
+	request, err := scanFriendRequest(r.db.QueryRowContext(ctx, query, senderID, recipientID))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == db.UniqueViolation {
+			return nil, ErrFriendRequestPending
+		}
+		return nil, fmt.Errorf("error creating friend request: %w", err)
+	}
+
+	return request, nil
+}
+
+// GetPendingFriendRequest returns the pending friend request between two
+// users in either direction, or nil if there is none
+func (r *Repository) GetPendingFriendRequest(ctx context.Context, userID, otherID string) (*models.FriendRequest, error) {
+	query := `
+		SELECT ` + friendRequestColumns + `
+		FROM friend_requests
+		WHERE status = 'pending' AND (
+			(sender_id = $1 AND recipient_id = $2) OR
+			(sender_id = $2 AND recipient_id = $1)
+		)
+	`
+
+	request, err := scanFriendRequest(r.db.QueryRowContext(ctx, query, userID, otherID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying friend request: %w", err)
+	}
+
+	return request, nil
+}
+
+// ResolveFriendRequest moves a pending friend request to status on behalf of
+// actorID, who must be the request's sender or recipient as named by
+// actorColumn. Accepting a request makes the two users friends.
+func (r *Repository) ResolveFriendRequest(ctx context.Context, requestID, actorColumn, actorID, status string) (*models.FriendRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current string
+	err = tx.QueryRowContext(ctx,
+		"SELECT status FROM friend_requests WHERE id = $1 AND "+actorColumn+" = $2 FOR UPDATE",
+		requestID, actorID).Scan(&current)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("friend request not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying friend request: %w", err)
+	}
+	if current != "pending" {
+		return nil, fmt.Errorf("friend request was already %s", current)
+	}
+
+	query := `
+		UPDATE friend_requests
+		SET status = $2, resolved_at = NOW()
+		WHERE id = $1
+		RETURNING ` + friendRequestColumns + `
+	`
+
+	request, err := scanFriendRequest(tx.QueryRowContext(ctx, query, requestID, status))
+	if err != nil {
+		return nil, fmt.Errorf("error updating friend request: %w", err)
+	}
+
+	if status == "accepted" {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO friendships (user_id, friend_id)
+			VALUES ($1, $2), ($2, $1)
+			ON CONFLICT (user_id, friend_id) DO NOTHING
+		`, request.SenderID, request.RecipientID); err != nil {
+			return nil, fmt.Errorf("error creating friendship: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing friend request: %w", err)
+	}
+
+	return request, nil
+}
+
+// AreFriends reports whether two users are friends
+func (r *Repository) AreFriends(ctx context.Context, userID, otherID string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM friendships WHERE user_id = $1 AND friend_id = $2)`
+
+	var friends bool
+	if err := r.db.QueryRowContext(ctx, query, userID, otherID).Scan(&friends); err != nil {
+		return false, fmt.Errorf("error querying friendship: %w", err)
+	}
+	return friends, nil
+}
+
+// RemoveFriendship ends a friendship for both users, reporting whether there was one
+func (r *Repository) RemoveFriendship(ctx context.Context, userID, friendID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)
+	`, userID, friendID)
+	if err != nil {
+		return false, fmt.Errorf("error removing friendship: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error removing friendship: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListFriends returns a page of the IDs of the user's friends that the viewer
+// may see, most recent friendships first
+func (r *Repository) ListFriends(ctx context.Context, viewerID, userID string, args db.PageArgs) (*db.Page[string], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT friendships.friend_id, friendships.created_at
+		FROM friendships
+		JOIN users ON users.id = friendships.friend_id
+		WHERE friendships.user_id = $1 AND ` + user.VisibleToFilter("$5") + ` AND (
+			$2::timestamptz IS NULL OR
+			friendships.created_at < $2 OR
+			(friendships.created_at = $2 AND friendships.friend_id > $3)
+		)
+		ORDER BY friendships.created_at DESC, friendships.friend_id
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit+1, viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing friends: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (string, []interface{}, error) {
+		var id string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			return "", nil, fmt.Errorf("error scanning friendship row: %w", err)
+		}
+		return id, []interface{}{createdAt, id}, nil
+	})
+}
+
+// ListIncomingFriendRequests returns a page of the pending friend requests
+// sent to the user by users they may see, newest first
+func (r *Repository) ListIncomingFriendRequests(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.FriendRequest], error) {
+	return r.listFriendRequests(ctx, "recipient_id", "sender_id", userID, args)
+}
+
+// ListOutgoingFriendRequests returns a page of the pending friend requests
+// the user sent to users they may see, newest first
+func (r *Repository) ListOutgoingFriendRequests(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.FriendRequest], error) {
+	return r.listFriendRequests(ctx, "sender_id", "recipient_id", userID, args)
+}
+
+// listFriendRequests pages through the pending friend requests whose match
+// column is userID and whose other column is a user they may see
+func (r *Repository) listFriendRequests(ctx context.Context, match, other, userID string, args db.PageArgs) (*db.Page[*models.FriendRequest], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + friendRequestColumns + `, friend_requests.created_at
+		FROM friend_requests
+		JOIN users ON users.id = friend_requests.` + other + `
+		WHERE friend_requests.` + match + ` = $1 AND
+			friend_requests.status = 'pending' AND
+			` + user.VisibleToFilter("$1") + ` AND (
+				$2::timestamptz IS NULL OR
+				friend_requests.created_at < $2 OR
+				(friend_requests.created_at = $2 AND friend_requests.id > $3)
+			)
+		ORDER BY friend_requests.created_at DESC, friend_requests.id
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error listing friend requests: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*models.FriendRequest, []interface{}, error) {
+		var createdAt time.Time
+		request, err := scanFriendRequest(db.WithExtra(rows, &createdAt))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning friend request row: %w", err)
+		}
+		return request, []interface{}{createdAt, request.ID}, nil
+	})
+}
+
+// Block records that blockerID blocked blockedID and severs every tie between
+// them: follows and friendships in both directions end and pending friend
+// requests are cancelled. Blocking someone already blocked is not an error.
+func (r *Repository) Block(ctx context.Context, blockerID, blockedID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_blocks (blocker_id, blocked_id)
+		VALUES ($1, $2)
+		ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+	`, blockerID, blockedID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == db.ForeignKeyViolation {
+			return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+		}
+		return fmt.Errorf("error blocking user: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM follows
+		WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)
+	`, blockerID, blockedID); err != nil {
+		return fmt.Errorf("error removing follows: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM friendships
+		WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)
+	`, blockerID, blockedID); err != nil {
+		return fmt.Errorf("error removing friendship: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE friend_requests
+		SET status = 'cancelled', resolved_at = NOW()
+		WHERE status = 'pending' AND (
+			(sender_id = $1 AND recipient_id = $2) OR
+			(sender_id = $2 AND recipient_id = $1)
+		)
+	`, blockerID, blockedID); err != nil {
+		return fmt.Errorf("error cancelling friend requests: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing block: %w", err)
+	}
+	return nil
+}
+
+// Unblock removes a block, reporting whether there was one
+func (r *Repository) Unblock(ctx context.Context, blockerID, blockedID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2", blockerID, blockedID)
+	if err != nil {
+		return false, fmt.Errorf("error unblocking user: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error unblocking user: %w", err)
+	}
+	return n > 0, nil
+}
+
+// Mute records that muterID muted mutedID. Muting someone already muted is not an error.
+func (r *Repository) Mute(ctx context.Context, muterID, mutedID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_mutes (muter_id, muted_id)
+		VALUES ($1, $2)
+		ON CONFLICT (muter_id, muted_id) DO NOTHING
+	`, muterID, mutedID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == db.ForeignKeyViolation {
+			return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+		}
+		return fmt.Errorf("error muting user: %w", err)
+	}
+	return nil
+}
+
+// Unmute removes a mute, reporting whether there was one
+func (r *Repository) Unmute(ctx context.Context, muterID, mutedID string) (bool, error) {
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM user_mutes WHERE muter_id = $1 AND muted_id = $2", muterID, mutedID)
+	if err != nil {
+		return false, fmt.Errorf("error unmuting user: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error unmuting user: %w", err)
+	}
+	return n > 0, nil
+}
+
+// ListBlocked returns a page of the IDs of the users the user blocked, most recent first
+func (r *Repository) ListBlocked(ctx context.Context, userID string, args db.PageArgs) (*db.Page[string], error) {
+	return r.listRelations(ctx, "user_blocks", "blocker_id", "blocked_id", userID, args)
+}
+
+// ListMuted returns a page of the IDs of the users the user muted, most recent first
+func (r *Repository) ListMuted(ctx context.Context, userID string, args db.PageArgs) (*db.Page[string], error) {
+	return r.listRelations(ctx, "user_mutes", "muter_id", "muted_id", userID, args)
+}
+
+// listRelations pages through the other column of the rows of table whose
+// match column is userID
+func (r *Repository) listRelations(ctx context.Context, table, match, other, userID string, args db.PageArgs) (*db.Page[string], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + other + `, created_at
+		FROM ` + table + `
+		WHERE ` + match + ` = $1 AND (
+			$2::timestamptz IS NULL OR
+			created_at < $2 OR
+			(created_at = $2 AND ` + other + ` > $3)
+		)
+		ORDER BY created_at DESC, ` + other + `
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (string, []interface{}, error) {
+		var id string
+		var createdAt time.Time
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			return "", nil, fmt.Errorf("error scanning %s row: %w", table, err)
+		}
+		return id, []interface{}{createdAt, id}, nil
+	})
+}
+
+// suggestionCandidate is a user scored by Suggestions, with what they have
+// in common with the viewer
+type suggestionCandidate struct {
+	UserID            string
+	MutualConnections int
+	SharedInterests   []string
+	SharedActivities  []string
+	SharedLanguages   []string
+	SharedTravelStyle *string
+	DistanceKm        *float64
+}
+
+// Suggestions returns at most limit users the viewer might want to connect
+// with, best first. A user's connections are the users they follow and their
+// friends; candidates score 2 for each connection they share with the viewer
+// (counting at most 10), 1 for each interest, activity and language they
+// share publicly, 2 for a shared travel style and up to 5 for being close to
+// origin. Candidates are close when a recent current location or their home
+// city, one of nearbyCities at the matching cityDistances, lies within
+// radiusKm. origin may be nil, in which case proximity doesn't count. Users
+// the viewer is already connected to, has a pending friend request with, has
+// dismissed, can't see or has muted are never suggested. Only users who share
+// something with the viewer can score, so that pool is gathered through the
+// connection graph, the array and travel style indexes and the location box
+// first, and only its members are scored.
+func (r *Repository) Suggestions(ctx context.Context, viewerID string, origin *geo.Point, radiusKm float64, sharedSince time.Time, nearbyCities []string, cityDistances []float64, limit int) ([]*suggestionCandidate, error) {
+	var lat, lng, minLat, maxLat, minLng, maxLng *float64
+	if origin != nil {
+		box := geo.BoundingBox(*origin, radiusKm)
+		lat, lng = &origin.Latitude, &origin.Longitude
+		minLat, maxLat = &box.MinLatitude, &box.MaxLatitude
+		minLng, maxLng = &box.MinLongitude, &box.MaxLongitude
+	}
+
+	publicInterests := user.PublicFieldFilter(models.ProfileFieldInterests)
+	publicTravelPreferences := user.PublicFieldFilter(models.ProfileFieldTravelPreferences)
+	query := `
+		WITH viewer AS (
+			SELECT users.interests, tp.travel_style, tp.preferred_activities, tp.languages_spoken
+			FROM users
+			LEFT JOIN travel_preferences tp ON tp.user_id = users.id
+			WHERE users.id = $1
+		),
+		viewer_connections AS (
+			SELECT followee_id AS id FROM follows WHERE follower_id = $1
+			UNION
+			SELECT friend_id FROM friendships WHERE user_id = $1
+		),
+		mutuals AS (
+			SELECT links.user_id, COUNT(DISTINCT links.via_id) AS mutual_count
+			FROM (
+				SELECT follows.follower_id AS user_id, follows.followee_id AS via_id
+				FROM follows
+				JOIN viewer_connections ON viewer_connections.id = follows.followee_id
+				UNION ALL
+				SELECT friendships.user_id, friendships.friend_id
+				FROM friendships
+				JOIN viewer_connections ON viewer_connections.id = friendships.friend_id
+			) links
+			JOIN users ON users.id = links.via_id
+			WHERE ` + user.VisibleToFilter("$1") + `
+			GROUP BY links.user_id
+		),
+		pool AS (
+			SELECT user_id AS id FROM mutuals
+			UNION
+			SELECT id FROM users WHERE interests && (SELECT interests FROM viewer)
+			UNION
+			SELECT user_id FROM travel_preferences
+			WHERE preferred_activities && (SELECT preferred_activities FROM viewer)
+			UNION
+			SELECT user_id FROM travel_preferences
+			WHERE languages_spoken && (SELECT languages_spoken FROM viewer)
+			UNION
+			SELECT user_id FROM travel_preferences
+			WHERE travel_style = (SELECT travel_style FROM viewer)
+			UNION
+			SELECT user_id FROM user_locations
+			WHERE updated_at >= $2 AND
+				latitude BETWEEN $5::double precision AND $6::double precision AND
+				longitude BETWEEN $7::double precision AND $8::double precision
+			UNION
+			SELECT id FROM users WHERE home_city_id = ANY($10)
+		),
+		candidates AS (
+			SELECT
+				users.id,
+				COALESCE(mutuals.mutual_count, 0) AS mutual_count,
+				CASE WHEN ` + publicInterests + ` THEN ARRAY(
+					SELECT unnest(users.interests) INTERSECT SELECT unnest(viewer.interests) ORDER BY 1
+				) ELSE '{}' END AS shared_interests,
+				CASE WHEN ` + publicTravelPreferences + ` THEN ARRAY(
+					SELECT unnest(tp.preferred_activities) INTERSECT SELECT unnest(viewer.preferred_activities) ORDER BY 1
+				) ELSE '{}' END AS shared_activities,
+				CASE WHEN ` + publicTravelPreferences + ` THEN ARRAY(
+					SELECT unnest(tp.languages_spoken) INTERSECT SELECT unnest(viewer.languages_spoken) ORDER BY 1
+				) ELSE '{}' END AS shared_languages,
+				CASE WHEN ` + publicTravelPreferences + ` AND tp.travel_style = viewer.travel_style
+					THEN tp.travel_style END AS shared_style,
+				LEAST(
+					CASE WHEN l.updated_at >= $2 AND
+						l.latitude BETWEEN $5::double precision AND $6::double precision AND
+						l.longitude BETWEEN $7::double precision AND $8::double precision
+					THEN 2 * 6371.0 * ASIN(LEAST(1, SQRT(
+						POWER(SIN(RADIANS(l.latitude - $3::double precision) / 2), 2) +
+						COS(RADIANS($3)) * COS(RADIANS(l.latitude)) * POWER(SIN(RADIANS(l.longitude - $4::double precision) / 2), 2)
+					))) END,
+					city.distance_km
+				) AS distance
+			FROM pool
+			JOIN users ON users.id = pool.id
+			CROSS JOIN viewer
+			LEFT JOIN travel_preferences tp ON tp.user_id = users.id
+			LEFT JOIN mutuals ON mutuals.user_id = users.id
+			LEFT JOIN user_locations l ON l.user_id = users.id
+			LEFT JOIN unnest($10::text[], $11::double precision[]) AS city(id, distance_km)
+				ON city.id = users.home_city_id
+			WHERE users.id <> $1 AND
+				` + user.VisibleToFilter("$1") + ` AND
+				` + user.NotMutedFilter("$1") + ` AND
+				NOT EXISTS (SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = users.id) AND
+				NOT EXISTS (SELECT 1 FROM friendships WHERE user_id = $1 AND friend_id = users.id) AND
+				NOT EXISTS (
+					SELECT 1 FROM friend_requests
+					WHERE status = 'pending' AND (
+						(sender_id = $1 AND recipient_id = users.id) OR
+						(sender_id = users.id AND recipient_id = $1)
+					)
+				) AND
+				NOT EXISTS (SELECT 1 FROM suggestion_dismissals WHERE user_id = $1 AND dismissed_id = users.id)
+		)
+		SELECT id, mutual_count, shared_interests, shared_activities, shared_languages, shared_style, distance
+		FROM (
+			SELECT
+				candidates.*,
+				2 * LEAST(mutual_count, 10) +
+					COALESCE(cardinality(shared_interests), 0) +
+					COALESCE(cardinality(shared_activities), 0) +
+					COALESCE(cardinality(shared_languages), 0) +
+					CASE WHEN shared_style IS NOT NULL THEN 2 ELSE 0 END +
+					CASE WHEN distance <= $9 THEN 5 * (1 - distance / $9) ELSE 0 END AS score
+			FROM candidates
+		) scored
+		WHERE score > 0
+		ORDER BY score DESC, id
+		LIMIT $12
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, viewerID, sharedSince, lat, lng,
+		minLat, maxLat, minLng, maxLng, radiusKm,
+		pq.Array(nearbyCities), pq.Array(cityDistances), limit)
+	if err != nil {
+		return nil, fmt.Errorf("error finding suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []*suggestionCandidate
+	for rows.Next() {
+		var c suggestionCandidate
+		var distance sql.NullFloat64
+		err := rows.Scan(&c.UserID, &c.MutualConnections, pq.Array(&c.SharedInterests),
+			pq.Array(&c.SharedActivities), pq.Array(&c.SharedLanguages), &c.SharedTravelStyle, &distance)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning suggestion row: %w", err)
+		}
+		if distance.Valid && distance.Float64 <= radiusKm {
+			c.DistanceKm = &distance.Float64
+		}
+		candidates = append(candidates, &c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return candidates, nil
+}
+
+// DismissSuggestion stops dismissedID from ever being suggested to userID.
+// Dismissing someone already dismissed is not an error.
+func (r *Repository) DismissSuggestion(ctx context.Context, userID, dismissedID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO suggestion_dismissals (user_id, dismissed_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, dismissed_id) DO NOTHING
+	`, userID, dismissedID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == db.ForeignKeyViolation {
+			return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+		}
+		return fmt.Errorf("error dismissing suggestion: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package social
+
+import (
+	"context"
+	"errors"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/user"
+)
+
+// ErrSelfFollow is returned when a user tries to follow themselves
+var ErrSelfFollow = errors.New("you can't follow yourself")
+
+// Service manages the relationships between users. It works with user IDs
+// and leaves loading the users themselves to the user service.
+type Service struct {
+	repo   *Repository
+	config *config.Config
+	users  *user.Service
+}
+
+func NewService(repo *Repository, config *config.Config, users *user.Service) *Service {
+	return &Service{
+		repo:   repo,
+		config: config,
+		users:  users,
+	}
+}
+
+// Follow makes followerID follow another visible user and returns that user
+func (s *Service) Follow(ctx context.Context, followerID, followeeID string) (*models.User, error) {
+	if followerID == followeeID {
+		return nil, ErrSelfFollow
+	}
+
+	followee, err := s.users.GetVisibleUserByID(ctx, followerID, followeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Follow(ctx, followerID, followeeID); err != nil {
+		return nil, err
+	}
+	return followee, nil
+}
+
+// Unfollow stops followerID following a user, reporting whether they did
+func (s *Service) Unfollow(ctx context.Context, followerID, followeeID string) (bool, error) {
+	return s.repo.Unfollow(ctx, followerID, followeeID)
+}
+
+// IsFollowing reports whether followerID follows followeeID
+func (s *Service) IsFollowing(ctx context.Context, followerID, followeeID string) (bool, error) {
+	if followerID == "" || followerID == followeeID {
+		return false, nil
+	}
+	return s.repo.IsFollowing(ctx, followerID, followeeID)
+}
+
+// FollowerCount returns how many of the users following the user the viewer can see
+func (s *Service) FollowerCount(ctx context.Context, viewerID, userID string) (int, error) {
+	return s.repo.CountFollowers(ctx, viewerID, userID)
+}
+
+// FollowingCount returns how many of the users the user follows the viewer can see
+func (s *Service) FollowingCount(ctx context.Context, viewerID, userID string) (int, error) {
+	return s.repo.CountFollowing(ctx, viewerID, userID)
+}
+
+// Followers returns a page of the users following the user that the viewer
+// can see, most recent first
+func (s *Service) Followers(ctx context.Context, viewerID, userID string, args db.PageArgs) (*db.Page[*models.User], error) {
+	ids, err := s.repo.ListFollowers(ctx, viewerID, userID, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadUsers(ctx, viewerID, ids)
+}
+
+// Following returns a page of the users the user follows that the viewer can
+// see, most recently followed first
+func (s *Service) Following(ctx context.Context, viewerID, userID string, args db.PageArgs) (*db.Page[*models.User], error) {
+	ids, err := s.repo.ListFollowing(ctx, viewerID, userID, args)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadUsers(ctx, viewerID, ids)
+}
+
+// loadUsers turns a page of user IDs into a page of users. Users that stopped
+// being visible since the IDs were read are dropped along with their cursors.
+func (s *Service) loadUsers(ctx context.Context, viewerID string, ids *db.Page[string]) (*db.Page[*models.User], error) {
+	users, err := s.users.GetVisibleUsersByIDs(ctx, viewerID, ids.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &db.Page[*models.User]{
+		Items:           users,
+		Cursors:         make([]string, 0, len(users)),
+		HasPreviousPage: ids.HasPreviousPage,
+		HasNextPage:     ids.HasNextPage,
+	}
+	next := 0
+	for i, id := range ids.Items {
+		if next < len(users) && users[next].ID == id {
+			page.Cursors = append(page.Cursors, ids.Cursors[i])
+			next++
+		}
+	}
+	return page, nil
+}
@@ -0,0 +1,125 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/geo"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// defaultSuggestions and maxSuggestions bound how many suggestions SuggestedTravelers returns
+const (
+	defaultSuggestions = 10
+	maxSuggestions     = 50
+)
+
+// SuggestedTravelers returns the users the viewer is most likely to know or
+// get on with, based on the connections they share, their public interests
+// and travel preferences, and how close they are, each with the reasons it
+// was suggested. Proximity is measured from the viewer's recent current
+// location or home city and only counts within the configured radius.
+func (s *Service) SuggestedTravelers(ctx context.Context, viewer *models.User, first *int) ([]*models.TravelerSuggestion, error) {
+	limit := defaultSuggestions
+	if first != nil {
+		if *first < 1 || *first > maxSuggestions {
+			return nil, fmt.Errorf("first must be between 1 and %d", maxSuggestions)
+		}
+		limit = *first
+	}
+
+	origin, err := s.users.ApproximateLocation(ctx, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	radiusKm := s.config.SuggestionRadiusKm
+	var cityIDs []string
+	var cityDistances []float64
+	if origin != nil {
+		var cities []*geo.City
+		cities, cityDistances = geo.CitiesWithin(*origin, radiusKm)
+		for _, city := range cities {
+			cityIDs = append(cityIDs, city.ID)
+		}
+	}
+
+	sharedSince := time.Now().Add(-s.config.CurrentLocationMaxAge)
+	candidates, err := s.repo.Suggestions(ctx, viewer.ID, origin, radiusKm, sharedSince, cityIDs, cityDistances, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.UserID
+	}
+	users, err := s.users.GetVisibleUsersByIDs(ctx, viewer.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// Users that stopped being visible since the candidates were read are dropped
+	suggestions := make([]*models.TravelerSuggestion, 0, len(users))
+	next := 0
+	for _, c := range candidates {
+		if next < len(users) && users[next].ID == c.UserID {
+			suggestions = append(suggestions, suggestionToModel(users[next], c))
+			next++
+		}
+	}
+	return suggestions, nil
+}
+
+// DismissSuggestion stops a user from ever being suggested to the viewer again
+func (s *Service) DismissSuggestion(ctx context.Context, viewerID, userID string) (bool, error) {
+	if viewerID == userID {
+		return false, errors.New("you can't dismiss yourself")
+	}
+
+	if err := s.repo.DismissSuggestion(ctx, viewerID, userID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// suggestionToModel converts a scored candidate to its GraphQL
+// representation along with the reasons it was suggested. Distances are
+// rounded to 100 m like those of nearby travelers.
+func suggestionToModel(u *models.User, c *suggestionCandidate) *models.TravelerSuggestion {
+	suggestion := &models.TravelerSuggestion{
+		User:                  u,
+		MutualConnectionCount: c.MutualConnections,
+		SharedInterests:       c.SharedInterests,
+		SharedActivities:      c.SharedActivities,
+		SharedLanguages:       c.SharedLanguages,
+		SharedTravelStyle:     c.SharedTravelStyle,
+	}
+	if c.DistanceKm != nil {
+		distance := math.Round(*c.DistanceKm*10) / 10
+		suggestion.DistanceKm = &distance
+	}
+
+	if c.MutualConnections > 0 {
+		suggestion.Reasons = append(suggestion.Reasons, models.SuggestionReasonMutualConnections)
+	}
+	if c.DistanceKm != nil {
+		suggestion.Reasons = append(suggestion.Reasons, models.SuggestionReasonNearby)
+	}
+	if len(c.SharedInterests) > 0 {
+		suggestion.Reasons = append(suggestion.Reasons, models.SuggestionReasonSharedInterests)
+	}
+	if c.SharedTravelStyle != nil {
+		suggestion.Reasons = append(suggestion.Reasons, models.SuggestionReasonSharedTravelStyle)
+	}
+	if len(c.SharedActivities) > 0 {
+		suggestion.Reasons = append(suggestion.Reasons, models.SuggestionReasonSharedActivities)
+	}
+	if len(c.SharedLanguages) > 0 {
+		suggestion.Reasons = append(suggestion.Reasons, models.SuggestionReasonSharedLanguages)
+	}
+	return suggestion
+}
@@ -0,0 +1,153 @@
+package token
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/lib/pq"
+)
+
+type Repository struct {
+	db *db.DB
+}
+
+func NewRepository(db *db.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// tokenColumns lists the personal_access_tokens columns read by scanToken, in order
+const tokenColumns = `id, name, prefix, scopes, expires_at, last_used_at, created_at`
+
+// scanToken reads a personal_access_tokens row selected with tokenColumns
+func scanToken(row db.RowScanner) (*models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	var expiresAt, lastUsedAt sql.NullTime
+	var createdAt time.Time
+
+	err := row.Scan(
+		&token.ID, &token.Name, &token.Prefix, pq.Array(&token.Scopes),
+		&expiresAt, &lastUsedAt, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		formatted := expiresAt.Time.Format(time.RFC3339)
+		token.ExpiresAt = &formatted
+	}
+	if lastUsedAt.Valid {
+		formatted := lastUsedAt.Time.Format(time.RFC3339)
+		token.LastUsedAt = &formatted
+	}
+	token.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return &token, nil
+}
+
+func (r *Repository) CreateToken(ctx context.Context, userID, name, prefix, tokenHash string, scopes []string, expiresAt *time.Time) (*models.PersonalAccessToken, error) {
+	query := `
+		INSERT INTO personal_access_tokens (id, user_id, name, prefix, token_hash, scopes, expires_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6)
+		RETURNING ` + tokenColumns + `
+	`
+
+	token, err := scanToken(r.db.QueryRowContext(ctx, query, userID, name, prefix, tokenHash,
+		pq.Array(scopes), expiresAt))
+	if err != nil {
+		return nil, fmt.Errorf("error creating access token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ListTokens returns a page of the user's tokens that have not been revoked,
+// newest first
+func (r *Repository) ListTokens(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.PersonalAccessToken], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + tokenColumns + `, created_at
+		FROM personal_access_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL AND (
+			$2::timestamptz IS NULL OR
+			created_at < $2 OR
+			(created_at = $2 AND id > $3)
+		)
+		ORDER BY created_at DESC, id
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error listing access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*models.PersonalAccessToken, []interface{}, error) {
+		var createdAt time.Time
+		token, err := scanToken(db.WithExtra(rows, &createdAt))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning access token row: %w", err)
+		}
+		return token, []interface{}{createdAt, token.ID}, nil
+	})
+}
+
+// RevokeToken revokes one of the user's tokens, reporting whether it existed
+func (r *Repository) RevokeToken(ctx context.Context, userID, id string) (bool, error) {
+	query := `
+		UPDATE personal_access_tokens
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("error revoking access token: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("error revoking access token: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// UseToken looks up an active token by hash and records that it was used
+func (r *Repository) UseToken(ctx context.Context, tokenHash string) (string, []string, error) {
+	query := `
+		UPDATE personal_access_tokens
+		SET last_used_at = NOW()
+		WHERE token_hash = $1
+			AND revoked_at IS NULL
+			AND (expires_at IS NULL OR expires_at > NOW())
+		RETURNING user_id, scopes
+	`
+
+	var userID string
+	var scopes []string
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&userID, pq.Array(&scopes))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, fmt.Errorf("access token not found: %w", err)
+		}
+		return "", nil, fmt.Errorf("error querying access token: %w", err)
+	}
+
+	return userID, scopes, nil
+}
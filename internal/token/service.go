@@ -0,0 +1,107 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// maxTokenLifetimeDays caps how long a personal access token can live
+const maxTokenLifetimeDays = 365
+
+type Service struct {
+	repo *Repository
+}
+
+func NewService(repo *Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// CreateToken issues a new personal access token. The plaintext token is only
+// returned here; just its hash is stored.
+func (s *Service) CreateToken(ctx context.Context, userID string, input models.CreatePersonalAccessTokenInput) (*models.CreatePersonalAccessTokenPayload, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, errors.New("token name is required")
+	}
+
+	if len(input.Scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	for _, scope := range input.Scopes {
+		if !auth.Scope(scope).Valid() {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+
+	var expiresAt *time.Time
+	if input.ExpiresInDays != nil {
+		days := *input.ExpiresInDays
+		if days < 1 || days > maxTokenLifetimeDays {
+			return nil, fmt.Errorf("expiresInDays must be between 1 and %d", maxTokenLifetimeDays)
+		}
+		t := time.Now().AddDate(0, 0, days)
+		expiresAt = &t
+	}
+
+	var secret [32]byte
+	if _, err := rand.Read(secret[:]); err != nil {
+		return nil, fmt.Errorf("error generating access token: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret[:])
+	plaintext := auth.PersonalAccessTokenPrefix + encoded
+
+	token, err := s.repo.CreateToken(ctx, userID, name, auth.PersonalAccessTokenPrefix+encoded[:6],
+		hashToken(plaintext), input.Scopes, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.CreatePersonalAccessTokenPayload{
+		Token:               plaintext,
+		PersonalAccessToken: token,
+	}, nil
+}
+
+func (s *Service) ListTokens(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.PersonalAccessToken], error) {
+	return s.repo.ListTokens(ctx, userID, args)
+}
+
+func (s *Service) RevokeToken(ctx context.Context, userID, id string) (bool, error) {
+	return s.repo.RevokeToken(ctx, userID, id)
+}
+
+// VerifyToken implements auth.TokenVerifier
+func (s *Service) VerifyToken(ctx context.Context, token string) (string, []auth.Scope, error) {
+	userID, stored, err := s.repo.UseToken(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil, auth.ErrInvalidToken
+		}
+		return "", nil, err
+	}
+
+	scopes := make([]auth.Scope, len(stored))
+	for i, scope := range stored {
+		scopes[i] = auth.Scope(scope)
+	}
+
+	return userID, scopes, nil
+}
+
+// hashToken derives the stored form of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
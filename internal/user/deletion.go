@@ -0,0 +1,106 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	ory "github.com/ory/client-go"
+)
+
+// ScheduleDeletion marks the user's account for deletion once the grace
+// period ends. The Kratos identity is deactivated straight away so the user
+// can't sign back in; RestoreAccount undoes both while the grace period lasts.
+// The users row is updated first and put back if the identity can't be
+// deactivated, so an account is never locked out without a deletion pending.
+// Personal access tokens are revoked along with that update and stay revoked
+// even if it is put back.
+func (s *Service) ScheduleDeletion(ctx context.Context, userID string) (*models.AccountDeletion, error) {
+	scheduledFor := time.Now().Add(s.config.AccountDeletionGracePeriod)
+	if err := s.repo.ScheduleDeletion(ctx, userID, scheduledFor); err != nil {
+		return nil, err
+	}
+
+	if err := s.setIdentityState(ctx, userID, "inactive"); err != nil {
+		if rollbackErr := s.repo.RestoreDeletionSchedule(ctx, userID, nil); rollbackErr != nil {
+			log.Printf("Failed to cancel deletion of %s after identity error: %v", userID, rollbackErr)
+		}
+		return nil, err
+	}
+
+	return &models.AccountDeletion{
+		ScheduledFor: scheduledFor.Format(time.RFC3339),
+	}, nil
+}
+
+// RestoreAccount cancels a pending deletion and reactivates the identity. The
+// deletion is put back if the identity can't be reactivated, so the purge
+// still cleans up an account nobody can sign in to.
+func (s *Service) RestoreAccount(ctx context.Context, userID string) (*models.User, error) {
+	user, schedule, err := s.repo.CancelDeletion(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.setIdentityState(ctx, userID, "active"); err != nil {
+		if rollbackErr := s.repo.RestoreDeletionSchedule(ctx, userID, schedule); rollbackErr != nil {
+			log.Printf("Failed to put back deletion of %s after identity error: %v", userID, rollbackErr)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// PurgeDeletedAccounts permanently removes accounts whose grace period has
+// ended: the Kratos identity first, then the users row, which cascades to
+// everything the user owns, and finally their uploaded media. It returns the
+// number of accounts purged.
+func (s *Service) PurgeDeletedAccounts(ctx context.Context) (int, error) {
+	ids, err := s.repo.ListDueDeletions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	client := s.kratosAdmin()
+	purged := 0
+	for _, id := range ids {
+		r, err := client.IdentityAPI.DeleteIdentity(ctx, id).Execute()
+		if err != nil && (r == nil || r.StatusCode != http.StatusNotFound) {
+			log.Printf("Failed to delete identity %s: %v", id, err)
+			continue
+		}
+
+		user, err := s.repo.GetUserByID(ctx, id)
+		if err != nil {
+			log.Printf("Failed to load user %s: %v", id, err)
+			continue
+		}
+
+		if err := s.repo.DeleteUser(ctx, id); err != nil {
+			log.Printf("Failed to delete user %s: %v", id, err)
+			continue
+		}
+		s.deleteProfilePicture(ctx, user.ProfilePictureKey)
+		purged++
+	}
+
+	return purged, nil
+}
+
+// setIdentityState activates or deactivates the user's Kratos identity
+func (s *Service) setIdentityState(ctx context.Context, userID, state string) error {
+	patch := ory.NewJsonPatch("replace", "/state")
+	patch.Value = state
+
+	_, _, err := s.kratosAdmin().IdentityAPI.PatchIdentity(ctx, userID).
+		JsonPatch([]ory.JsonPatch{*patch}).
+		Execute()
+	if err != nil {
+		return fmt.Errorf("failed to set identity state to %s: %w", state, err)
+	}
+	return nil
+}
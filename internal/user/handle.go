@@ -0,0 +1,135 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// ErrHandleTaken is returned when another user holds the handle, either as
+// their current handle or as a redirect from an old one
+var ErrHandleTaken = errors.New("handle is already taken")
+
+// handlePattern allows 3 to 30 letters, digits and underscores, starting
+// with a letter so handles can't be confused with IDs
+var handlePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{2,29}$`)
+
+// reservedHandles can't be claimed because they clash with routes or could be
+// used to impersonate the service or its staff. Entries are lower case.
+var reservedHandles = map[string]bool{
+	"about":         true,
+	"account":       true,
+	"admin":         true,
+	"administrator": true,
+	"api":           true,
+	"explore":       true,
+	"graphql":       true,
+	"health":        true,
+	"help":          true,
+	"login":         true,
+	"logout":        true,
+	"media":         true,
+	"moderator":     true,
+	"null":          true,
+	"official":      true,
+	"playground":    true,
+	"privacy":       true,
+	"query":         true,
+	"register":      true,
+	"root":          true,
+	"search":        true,
+	"security":      true,
+	"settings":      true,
+	"signup":        true,
+	"staff":         true,
+	"support":       true,
+	"system":        true,
+	"terms":         true,
+	"travel_social": true,
+	"undefined":     true,
+	"webhooks":      true,
+}
+
+// NormalizeHandle strips surrounding whitespace and the leading @ users tend
+// to type. Case is preserved for display; comparisons ignore it.
+func NormalizeHandle(handle string) string {
+	return strings.TrimPrefix(strings.TrimSpace(handle), "@")
+}
+
+// handleUnavailableReason reports why userID can't claim the handle, or nil
+// when it can. Users may always reclaim their own current or old handles.
+func (s *Service) handleUnavailableReason(ctx context.Context, userID, handle string) (*models.HandleUnavailableReason, error) {
+	reason := models.HandleUnavailableReasonInvalid
+	if !handlePattern.MatchString(handle) {
+		return &reason, nil
+	}
+
+	if reservedHandles[strings.ToLower(handle)] {
+		reason = models.HandleUnavailableReasonReserved
+		return &reason, nil
+	}
+
+	owner, err := s.repo.GetHandleOwner(ctx, handle)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if owner != userID {
+		reason = models.HandleUnavailableReasonTaken
+		return &reason, nil
+	}
+
+	return nil, nil
+}
+
+// CheckHandleAvailability reports whether userID could take the handle
+func (s *Service) CheckHandleAvailability(ctx context.Context, userID, handle string) (*models.HandleAvailability, error) {
+	handle = NormalizeHandle(handle)
+
+	reason, err := s.handleUnavailableReason(ctx, userID, handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.HandleAvailability{
+		Handle:    handle,
+		Available: reason == nil,
+		Reason:    reason,
+	}, nil
+}
+
+// SetHandle claims a handle for a user. Picking a first handle is free, but
+// after that it can only change once per HandleChangeCooldown. Old handles
+// keep redirecting to the user and can't be claimed by anyone else.
+func (s *Service) SetHandle(ctx context.Context, userID, handle string) (*models.User, error) {
+	handle = NormalizeHandle(handle)
+
+	reason, err := s.handleUnavailableReason(ctx, userID, handle)
+	if err != nil {
+		return nil, err
+	}
+	if reason != nil {
+		switch *reason {
+		case models.HandleUnavailableReasonReserved:
+			return nil, errors.New("handle is reserved")
+		case models.HandleUnavailableReasonTaken:
+			return nil, ErrHandleTaken
+		default:
+			return nil, errors.New("handle must be 3 to 30 letters, digits or underscores and start with a letter")
+		}
+	}
+
+	return s.repo.ChangeHandle(ctx, userID, handle, s.config.HandleChangeCooldown)
+}
+
+// GetVisibleUserByHandle looks a user up for the viewer by their current or a
+// previous handle. Callers can tell the latter apart by comparing the returned handle.
+func (s *Service) GetVisibleUserByHandle(ctx context.Context, viewerID, handle string) (*models.User, error) {
+	return s.repo.GetVisibleUserByHandle(ctx, viewerID, NormalizeHandle(handle))
+}
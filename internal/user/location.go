@@ -0,0 +1,166 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/geo"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// defaultCityResults and maxCityResults bound how many cities SearchCities returns
+const (
+	defaultCityResults = 10
+	maxCityResults     = 50
+)
+
+// NearbyTraveler is a user found by NearbyTravelers with their distance from the searcher
+type NearbyTraveler struct {
+	User       *models.User
+	DistanceKm float64
+}
+
+// cityToModel converts a gazetteer entry to its GraphQL representation
+func cityToModel(city *geo.City) *models.City {
+	return &models.City{
+		ID:        city.ID,
+		Name:      city.Name,
+		Country:   city.Country,
+		Latitude:  city.Latitude,
+		Longitude: city.Longitude,
+	}
+}
+
+// HomeCity returns the user's home city, or nil if they haven't set one or
+// it is no longer in the gazetteer
+func HomeCity(user *models.User) *models.City {
+	if user.HomeCityID == nil {
+		return nil
+	}
+	city, ok := geo.LookupCity(*user.HomeCityID)
+	if !ok {
+		return nil
+	}
+	return cityToModel(city)
+}
+
+// SearchCities resolves a place name to cities in the gazetteer
+func (s *Service) SearchCities(query string, first *int) ([]*models.City, error) {
+	limit := defaultCityResults
+	if first != nil {
+		if *first < 1 || *first > maxCityResults {
+			return nil, fmt.Errorf("first must be between 1 and %d", maxCityResults)
+		}
+		limit = *first
+	}
+
+	cities := geo.SearchCities(query, limit)
+	result := make([]*models.City, len(cities))
+	for i, city := range cities {
+		result[i] = cityToModel(city)
+	}
+	return result, nil
+}
+
+// SetHomeCity sets the user's home city to a gazetteer city, or clears it given nil
+func (s *Service) SetHomeCity(ctx context.Context, userID string, cityID *string) (*models.User, error) {
+	if cityID != nil {
+		if _, ok := geo.LookupCity(*cityID); !ok {
+			return nil, errors.New("unknown city, use searchCities to find its ID")
+		}
+	}
+
+	return s.repo.SetHomeCity(ctx, userID, cityID)
+}
+
+// GetCurrentLocation returns the coarsened location a user shares, or nil
+func (s *Service) GetCurrentLocation(ctx context.Context, userID string) (*models.Location, error) {
+	return s.repo.GetCurrentLocation(ctx, userID)
+}
+
+// SetCurrentLocation opts the user in to sharing their current location, or
+// given nil opts them out. Only a coarsened position is ever stored.
+func (s *Service) SetCurrentLocation(ctx context.Context, userID string, input *models.LocationInput) (*models.User, error) {
+	if input == nil {
+		if err := s.repo.DeleteCurrentLocation(ctx, userID); err != nil {
+			return nil, err
+		}
+		return s.repo.GetUserByID(ctx, userID)
+	}
+
+	point := geo.Point{Latitude: input.Latitude, Longitude: input.Longitude}
+	if err := point.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveCurrentLocation(ctx, userID, point.Coarsen()); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetUserByID(ctx, userID)
+}
+
+// NearbyTravelers returns a page of the users sharing their current location
+// within radiusKm of the viewer, nearest first. The search starts from the
+// viewer's own current location when they share a recent one, and from their
+// home city otherwise. Users who don't share their location, or haven't
+// updated it within the configured maximum age, are left out. Distances are
+// rounded to 100 m, which is finer than the stored locations anyway.
+func (s *Service) NearbyTravelers(ctx context.Context, viewer *models.User, radiusKm float64, args db.PageArgs) (*db.Page[*NearbyTraveler], error) {
+	maxRadius := s.config.NearbyMaxRadiusKm
+	if math.IsNaN(radiusKm) || radiusKm <= 0 || radiusKm > maxRadius {
+		return nil, fmt.Errorf("radiusKm must be greater than 0 and at most %g", maxRadius)
+	}
+
+	origin, err := s.searchOrigin(ctx, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSince := time.Now().Add(-s.config.CurrentLocationMaxAge)
+	page, err := s.repo.NearbyTravelers(ctx, viewer.ID, *origin, radiusKm, sharedSince, args)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, traveler := range page.Items {
+		traveler.DistanceKm = math.Round(traveler.DistanceKm*10) / 10
+	}
+	return page, nil
+}
+
+// searchOrigin returns where a nearby search for the viewer starts from
+func (s *Service) searchOrigin(ctx context.Context, viewer *models.User) (*geo.Point, error) {
+	origin, err := s.ApproximateLocation(ctx, viewer)
+	if err != nil {
+		return nil, err
+	}
+	if origin == nil {
+		return nil, errors.New("share your current location or set a home city to find travelers nearby")
+	}
+	return origin, nil
+}
+
+// ApproximateLocation returns where the user is as far as they have shared:
+// their current location when it is recent, their home city otherwise, or
+// nil when they have shared neither
+func (s *Service) ApproximateLocation(ctx context.Context, u *models.User) (*geo.Point, error) {
+	location, err := s.repo.GetCurrentLocation(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if location != nil {
+		updatedAt, err := time.Parse(time.RFC3339, location.UpdatedAt)
+		if err == nil && time.Since(updatedAt) <= s.config.CurrentLocationMaxAge {
+			return &geo.Point{Latitude: location.Latitude, Longitude: location.Longitude}, nil
+		}
+	}
+
+	if city := HomeCity(u); city != nil {
+		return &geo.Point{Latitude: city.Latitude, Longitude: city.Longitude}, nil
+	}
+	return nil, nil
+}
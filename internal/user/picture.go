@@ -0,0 +1,143 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/imaging"
+)
+
+// profilePictureTypes lists the accepted profile picture content types
+var profilePictureTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// ProfilePictureSizes maps each profile picture size to the width and height
+// of its square thumbnail, in pixels
+var ProfilePictureSizes = map[models.ProfilePictureSize]int{
+	models.ProfilePictureSizeSmall:  64,
+	models.ProfilePictureSizeMedium: 256,
+	models.ProfilePictureSizeLarge:  512,
+}
+
+// ProfilePictureKey returns the blob key of one size of an uploaded profile
+// picture. Pictures uploaded before thumbnails were generated are stored as a
+// single blob with an extension, which is used for every size.
+func ProfilePictureKey(base string, size models.ProfilePictureSize) string {
+	if path.Ext(base) != "" {
+		return base
+	}
+	return path.Join(base, strings.ToLower(string(size))+".jpg")
+}
+
+// UploadProfilePicture validates an uploaded image and stores square JPEG
+// thumbnails of it in every ProfilePictureSizes size as the user's profile
+// picture. The content type is sniffed from the data rather than trusting
+// what the client declared, and the original is never stored, so EXIF data
+// such as the location a photo was taken at is dropped.
+func (s *Service) UploadProfilePicture(ctx context.Context, userID string, file io.Reader, size int64) (*models.User, error) {
+	maxSize := s.config.ProfilePictureMaxSize
+	if size > maxSize {
+		return nil, fmt.Errorf("profile picture must be at most %d bytes", maxSize)
+	}
+
+	// Read one byte past the limit so an understated size is caught too
+	data, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile picture: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("profile picture must be at most %d bytes", maxSize)
+	}
+
+	if contentType := http.DetectContentType(data); !profilePictureTypes[contentType] {
+		return nil, fmt.Errorf("unsupported profile picture type %s, use JPEG, PNG or WebP", contentType)
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := newBlobName()
+	if err != nil {
+		return nil, err
+	}
+	base := path.Join("avatars", userID, name)
+
+	for pictureSize, pixels := range ProfilePictureSizes {
+		thumbnail, err := imaging.EncodeJPEG(img.SquareThumbnail(pixels))
+		if err != nil {
+			s.deleteProfilePicture(ctx, &base)
+			return nil, err
+		}
+
+		key := ProfilePictureKey(base, pictureSize)
+		if err := s.media.Put(ctx, key, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/jpeg"); err != nil {
+			s.deleteProfilePicture(ctx, &base)
+			return nil, err
+		}
+	}
+
+	user, err := s.repo.SetProfilePictureKey(ctx, userID, base)
+	if err != nil {
+		s.deleteProfilePicture(ctx, &base)
+		return nil, err
+	}
+
+	s.deleteProfilePicture(ctx, current.ProfilePictureKey)
+	return user, nil
+}
+
+// deleteProfilePicture removes every blob of an uploaded profile picture
+func (s *Service) deleteProfilePicture(ctx context.Context, base *string) {
+	if base == nil {
+		return
+	}
+
+	if path.Ext(*base) != "" {
+		s.deleteBlob(ctx, base)
+		return
+	}
+	for pictureSize := range ProfilePictureSizes {
+		key := ProfilePictureKey(*base, pictureSize)
+		s.deleteBlob(ctx, &key)
+	}
+}
+
+// deleteBlob removes a blob that is no longer referenced. Failures only leave
+// an orphaned file behind, so they are logged rather than returned.
+func (s *Service) deleteBlob(ctx context.Context, key *string) {
+	if key == nil {
+		return
+	}
+
+	if err := s.media.Delete(ctx, *key); err != nil {
+		log.Printf("Failed to delete blob %s: %v", *key, err)
+	}
+}
+
+// newBlobName generates a random, unguessable blob name
+func newBlobName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate blob name: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
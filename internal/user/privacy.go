@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"strings"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// defaultPrivacySettings are stored for every user when they are provisioned,
+// so queries filtering on privacy_settings never need defaults of their own.
+// Email is private unless its owner chooses to share it.
+func defaultPrivacySettings() *models.PrivacySettings {
+	return &models.PrivacySettings{
+		Email:             models.VisibilityOnlyMe,
+		LastName:          models.VisibilityPublic,
+		Bio:               models.VisibilityPublic,
+		Interests:         models.VisibilityPublic,
+		TravelPreferences: models.VisibilityPublic,
+	}
+}
+
+// visibilityFromModel converts a GraphQL visibility to the value stored in privacy_settings
+func visibilityFromModel(visibility models.Visibility) string {
+	return strings.ToLower(string(visibility))
+}
+
+// visibilityToModel converts a stored visibility to its GraphQL representation
+func visibilityToModel(visibility string) models.Visibility {
+	return models.Visibility(strings.ToUpper(visibility))
+}
+
+// FieldVisibility returns the setting that governs a profile field
+func FieldVisibility(settings *models.PrivacySettings, field models.ProfileField) models.Visibility {
+	switch field {
+	case models.ProfileFieldEmail:
+		return settings.Email
+	case models.ProfileFieldLastName:
+		return settings.LastName
+	case models.ProfileFieldBio:
+		return settings.Bio
+	case models.ProfileFieldInterests:
+		return settings.Interests
+	case models.ProfileFieldTravelPreferences:
+		return settings.TravelPreferences
+	}
+
+	// Unknown fields are never shared
+	return models.VisibilityOnlyMe
+}
+
+// ProvisionPrivacySettings stores the default privacy settings for users who
+// have none, such as users provisioned before the defaults were stored
+func (s *Service) ProvisionPrivacySettings(ctx context.Context) (int64, error) {
+	return s.repo.CreateMissingPrivacySettings(ctx, defaultPrivacySettings())
+}
+
+// GetPrivacySettings returns a user's privacy settings, falling back to the defaults
+func (s *Service) GetPrivacySettings(ctx context.Context, userID string) (*models.PrivacySettings, error) {
+	settings, err := s.repo.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return defaultPrivacySettings(), nil
+	}
+
+	return settings, nil
+}
+
+// UpdatePrivacySettings changes the given privacy settings, leaving the rest as they are
+func (s *Service) UpdatePrivacySettings(ctx context.Context, userID string, input models.UpdatePrivacySettingsInput) (*models.PrivacySettings, error) {
+	settings, err := s.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Email != nil {
+		settings.Email = *input.Email
+	}
+	if input.LastName != nil {
+		settings.LastName = *input.LastName
+	}
+	if input.Bio != nil {
+		settings.Bio = *input.Bio
+	}
+	if input.Interests != nil {
+		settings.Interests = *input.Interests
+	}
+	if input.TravelPreferences != nil {
+		settings.TravelPreferences = *input.TravelPreferences
+	}
+
+	return s.repo.SavePrivacySettings(ctx, userID, settings)
+}
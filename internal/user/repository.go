@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/geo"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
 	"github.com/lib/pq"
 )
@@ -20,136 +24,942 @@ func NewRepository(db *db.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// userColumns lists the users columns read by scanUser, in order
+const userColumns = `id, email, first_name, last_name, profile_picture, bio, interests, role,
+		       handle, profile_picture_key, home_city_id, created_at, updated_at`
+
+// scanUser reads a users row selected with userColumns
+func scanUser(row db.RowScanner) (*models.User, error) {
+	var user models.User
+	var email, role string
+	var firstName, lastName, profilePicture, bio, handle, profilePictureKey, homeCityID sql.NullString
+	var interests []sql.NullString
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(
+		&user.ID, &email, &firstName, &lastName, &profilePicture, &bio,
+		pq.Array(&interests), &role, &handle, &profilePictureKey, &homeCityID, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Email is optional in the schema because privacy settings can hide it
+	user.Email = &email
+
+	// Convert null strings to pointers
+	if firstName.Valid {
+		user.FirstName = &firstName.String
+	}
+	if lastName.Valid {
+		user.LastName = &lastName.String
+	}
+	if profilePicture.Valid {
+		user.ProfilePicture = &profilePicture.String
+	}
+	if bio.Valid {
+		user.Bio = &bio.String
+	}
+	if handle.Valid {
+		user.Handle = &handle.String
+	}
+	if profilePictureKey.Valid {
+		user.ProfilePictureKey = &profilePictureKey.String
+	}
+	if homeCityID.Valid {
+		user.HomeCityID = &homeCityID.String
+	}
+
+	// Convert sql.NullString array to string array
+	for _, i := range interests {
+		if i.Valid {
+			user.Interests = append(user.Interests, i.String)
+		}
+	}
+
+	user.Role = roleToModel(auth.Role(role))
+	user.CreatedAt = createdAt.Format(time.RFC3339)
+	user.UpdatedAt = updatedAt.Format(time.RFC3339)
+
+	return &user, nil
+}
+
+// activeSuspensionFilter matches user_suspensions rows that are in force
+const activeSuspensionFilter = `lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+// visibleUserFilter limits queries to users other people may see, hiding
+// accounts that are pending deletion or suspended
+const visibleUserFilter = `deletion_requested_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM user_suspensions
+			WHERE user_suspensions.user_id = users.id AND ` + activeSuspensionFilter + `
+		)`
+
+// VisibleToFilter limits queries to the users a viewer may see: visible users
+// with no block between them and the viewer, whichever of them blocked the
+// other. viewer is the placeholder holding the viewer's ID. Queries in other
+// packages that join users use it too, so a block hides both users from each
+// other everywhere and looks no different from the account not existing.
+func VisibleToFilter(viewer string) string {
+	return visibleUserFilter + ` AND NOT EXISTS (
+			SELECT 1 FROM user_blocks
+			WHERE (user_blocks.blocker_id = users.id AND user_blocks.blocked_id = ` + viewer + `) OR
+				(user_blocks.blocker_id = ` + viewer + ` AND user_blocks.blocked_id = users.id)
+		)`
+}
+
+// NotMutedFilter leaves out the users a viewer muted. It applies to discovery
+// queries such as searches only; muted users can still be looked up directly.
+func NotMutedFilter(viewer string) string {
+	return `NOT EXISTS (
+			SELECT 1 FROM user_mutes
+			WHERE user_mutes.muter_id = ` + viewer + ` AND user_mutes.muted_id = users.id
+		)`
+}
+
 func (r *Repository) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	query := `
-		SELECT id, email, first_name, last_name, profile_picture, bio, interests, 
-		       created_at, updated_at
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = $1
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetVisibleUserByID returns a user as seen by the viewer, hiding accounts
+// that are pending deletion, suspended or blocked
+func (r *Repository) GetVisibleUserByID(ctx context.Context, viewerID, id string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = $1 AND ` + VisibleToFilter("$2") + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id, viewerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetVisibleUsersByIDs returns the users among ids the viewer may see, in no particular order
+func (r *Repository) GetVisibleUsersByIDs(ctx context.Context, viewerID string, ids []string) ([]*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE id = ANY($1) AND ` + VisibleToFilter("$2") + `
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids), viewerID)
+	if err != nil {
+		return nil, fmt.Errorf("error querying users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning user row: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetVisibleUserByHandle returns the user the viewer may see that currently
+// holds the handle or used to, matching case-insensitively
+func (r *Repository) GetVisibleUserByHandle(ctx context.Context, viewerID, handle string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE (
+			LOWER(handle) = LOWER($1) OR
+			id IN (SELECT user_id FROM handle_redirects WHERE LOWER(handle_redirects.handle) = LOWER($1))
+		) AND ` + VisibleToFilter("$2") + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, handle, viewerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetHandleOwner returns the ID of the user holding the handle, either as
+// their current handle or as a redirect from an old one
+func (r *Repository) GetHandleOwner(ctx context.Context, handle string) (string, error) {
+	query := `
+		SELECT id FROM users WHERE LOWER(handle) = LOWER($1)
+		UNION ALL
+		SELECT user_id FROM handle_redirects WHERE LOWER(handle) = LOWER($1)
+		LIMIT 1
+	`
+
+	var userID string
+	if err := r.db.QueryRowContext(ctx, query, handle).Scan(&userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("handle not found: %w", err)
+		}
+		return "", fmt.Errorf("error querying handle: %w", err)
+	}
+
+	return userID, nil
+}
+
+// ChangeHandle gives a user a new handle. The previous handle is kept as a
+// redirect to the user, and a redirect the user is reclaiming is dropped.
+// Picking a first handle is free, but after that it can only change once per
+// cooldown. The cooldown is checked under the lock on the users row, so
+// concurrent changes can't both get through.
+func (r *Repository) ChangeHandle(ctx context.Context, userID, handle string, cooldown time.Duration) (*models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var previous sql.NullString
+	var changedAt sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT handle, handle_changed_at FROM users WHERE id = $1 FOR UPDATE", userID).
+		Scan(&previous, &changedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying handle: %w", err)
+	}
+
+	if previous.Valid && previous.String == handle {
+		user, err := scanUser(tx.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", userID))
+		if err != nil {
+			return nil, fmt.Errorf("error querying user: %w", err)
+		}
+		return user, nil
+	}
+	if previous.Valid && changedAt.Valid {
+		if next := changedAt.Time.Add(cooldown); time.Now().Before(next) {
+			return nil, fmt.Errorf("handle can be changed again after %s", next.Format(time.RFC3339))
+		}
+	}
+
+	// Current handles and redirects are kept unique by separate indexes, so
+	// the handles involved are locked before checking that nobody else holds
+	// the new one in either table. Locks are taken in a fixed order so two
+	// users swapping handles can't deadlock.
+	locked := []string{strings.ToLower(handle)}
+	if previous.Valid && !strings.EqualFold(previous.String, handle) {
+		locked = append(locked, strings.ToLower(previous.String))
+	}
+	sort.Strings(locked)
+	for _, h := range locked {
+		if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext('handle:' || $1))", h); err != nil {
+			return nil, fmt.Errorf("error locking handle: %w", err)
+		}
+	}
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(handle) = LOWER($2) AND id <> $1) OR
+			EXISTS(SELECT 1 FROM handle_redirects WHERE LOWER(handle) = LOWER($2) AND user_id <> $1)
+	`, userID, handle).Scan(&taken)
+	if err != nil {
+		return nil, fmt.Errorf("error checking handle: %w", err)
+	}
+	if taken {
+		return nil, ErrHandleTaken
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM handle_redirects WHERE user_id = $1 AND LOWER(handle) = LOWER($2)",
+		userID, handle); err != nil {
+		return nil, fmt.Errorf("error reclaiming handle: %w", err)
+	}
+
+	// A change of case only doesn't need a redirect
+	if previous.Valid && !strings.EqualFold(previous.String, handle) {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO handle_redirects (handle, user_id) VALUES ($1, $2)",
+			previous.String, userID); err != nil {
+			return nil, fmt.Errorf("error recording handle redirect: %w", err)
+		}
+	}
+
+	query := `
+		UPDATE users
+		SET handle = $2, handle_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(tx.QueryRowContext(ctx, query, userID, handle))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == db.UniqueViolation {
+			return nil, ErrHandleTaken
+		}
+		return nil, fmt.Errorf("error updating handle: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing handle change: %w", err)
+	}
+
+	return user, nil
+}
+
+func (r *Repository) CreateUser(ctx context.Context, id, email string) (*models.User, error) {
+	query := `
+		INSERT INTO users (id, email)
+		VALUES ($1, $2)
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id, email))
+	if err != nil {
+		return nil, fmt.Errorf("error creating user: %w", err)
+	}
+
+	return user, nil
+}
+
+// CreateUserIfNotExists inserts a users row for a Kratos identity along with
+// its initial privacy settings, leaving an existing row untouched, and returns
+// the stored user
+func (r *Repository) CreateUserIfNotExists(ctx context.Context, id, email string, firstName, lastName *string, settings *models.PrivacySettings) (*models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO users (id, email, first_name, last_name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO NOTHING
+	`
+
+	if _, err := tx.ExecContext(ctx, query, id, email, firstName, lastName); err != nil {
+		return nil, fmt.Errorf("error creating user: %w", err)
+	}
+
+	query = `
+		INSERT INTO privacy_settings (user_id, email, last_name, bio, interests, travel_preferences)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO NOTHING
+	`
+
+	if _, err := tx.ExecContext(ctx, query, append([]interface{}{id}, privacySettingsArgs(settings)...)...); err != nil {
+		return nil, fmt.Errorf("error creating privacy settings: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("error committing user: %w", err)
+	}
+
+	return r.GetUserByID(ctx, id)
+}
+
+// CreateMissingPrivacySettings stores settings for every user without a
+// privacy_settings row, such as users provisioned before rows were stored
+// with the user, and returns how many were created
+func (r *Repository) CreateMissingPrivacySettings(ctx context.Context, settings *models.PrivacySettings) (int64, error) {
+	query := `
+		INSERT INTO privacy_settings (user_id, email, last_name, bio, interests, travel_preferences)
+		SELECT id, $1, $2, $3, $4, $5 FROM users
+		ON CONFLICT (user_id) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, privacySettingsArgs(settings)...)
+	if err != nil {
+		return 0, fmt.Errorf("error creating privacy settings: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *Repository) UpdateProfile(ctx context.Context, userID string, input models.UpdateProfileInput) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET 
+			first_name = COALESCE($2, first_name),
+			last_name = COALESCE($3, last_name),
+			profile_picture = COALESCE($4, profile_picture),
+			profile_picture_key = CASE WHEN $4::text IS NOT NULL THEN NULL ELSE profile_picture_key END,
+			bio = COALESCE($5, bio),
+			interests = CASE WHEN $6::text[] IS NOT NULL THEN $6::text[] ELSE interests END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, userID, input.FirstName, input.LastName,
+		input.ProfilePicture, input.Bio, pq.Array(input.Interests)))
+	if err != nil {
+		return nil, fmt.Errorf("error updating profile: %w", err)
+	}
+
+	return user, nil
+}
+
+// SetProfilePictureKey makes an uploaded blob the user's profile picture,
+// replacing any profile picture URL
+func (r *Repository) SetProfilePictureKey(ctx context.Context, userID, key string) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET profile_picture_key = $2, profile_picture = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, userID, key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error updating profile picture: %w", err)
+	}
+
+	return user, nil
+}
+
+// ClearProfileContent removes the user's bio, interests and profile picture
+// within tx, also returning the key of the removed picture so its blobs can be
+// deleted once tx commits
+func (r *Repository) ClearProfileContent(ctx context.Context, tx *sql.Tx, userID string) (*models.User, *string, error) {
+	query := `
+		WITH previous AS (
+			SELECT profile_picture_key FROM users WHERE id = $1 FOR UPDATE
+		)
+		UPDATE users
+		SET bio = NULL, interests = NULL, profile_picture = NULL, profile_picture_key = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `, (SELECT profile_picture_key FROM previous)
+	`
+
+	var pictureKey sql.NullString
+	user, err := scanUser(db.WithExtra(tx.QueryRowContext(ctx, query, userID), &pictureKey))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, nil, fmt.Errorf("error clearing profile content: %w", err)
+	}
+
+	if !pictureKey.Valid {
+		return user, nil, nil
+	}
+	return user, &pictureKey.String, nil
+}
+
+// SetHomeCity sets or, given nil, clears the user's home city
+func (r *Repository) SetHomeCity(ctx context.Context, userID string, cityID *string) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET home_city_id = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, userID, cityID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error updating home city: %w", err)
+	}
+
+	return user, nil
+}
+
+// SyncIdentityTraits overwrites the identity fields mirrored from Kratos
+func (r *Repository) SyncIdentityTraits(ctx context.Context, id, email string, firstName, lastName *string) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET email = $2, first_name = $3, last_name = $4, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id, email, firstName, lastName))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error syncing identity traits: %w", err)
+	}
+
+	return user, nil
+}
+
+// identityFields are the users columns mirrored from Kratos identity traits
+type identityFields struct {
+	Email     string
+	FirstName *string
+	LastName  *string
+}
+
+// ListIdentityFields returns the mirrored identity fields of every user, keyed by ID
+func (r *Repository) ListIdentityFields(ctx context.Context) (map[string]identityFields, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, email, first_name, last_name FROM users")
+	if err != nil {
+		return nil, fmt.Errorf("error listing users: %w", err)
+	}
+	defer rows.Close()
+
+	fields := make(map[string]identityFields)
+	for rows.Next() {
+		var id string
+		var f identityFields
+		var firstName, lastName sql.NullString
+		if err := rows.Scan(&id, &f.Email, &firstName, &lastName); err != nil {
+			return nil, fmt.Errorf("error scanning user row: %w", err)
+		}
+
+		if firstName.Valid {
+			f.FirstName = &firstName.String
+		}
+		if lastName.Valid {
+			f.LastName = &lastName.String
+		}
+		fields[id] = f
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// ScheduleDeletion marks the user's account for deletion at scheduledFor and
+// revokes their personal access tokens in the same transaction, so no token
+// outlives the request to leave
+func (r *Repository) ScheduleDeletion(ctx context.Context, userID string, scheduledFor time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET deletion_requested_at = NOW(), deletion_scheduled_for = $2, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID, scheduledFor)
+	if err != nil {
+		return fmt.Errorf("error scheduling account deletion: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error scheduling account deletion: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("user not found: %w", sql.ErrNoRows)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE personal_access_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID); err != nil {
+		return fmt.Errorf("error revoking access tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing account deletion: %w", err)
+	}
+	return nil
+}
+
+// DeletionSchedule is when a user asked for their account to be deleted and
+// when the deletion is due
+type DeletionSchedule struct {
+	RequestedAt  time.Time
+	ScheduledFor time.Time
+}
+
+// CancelDeletion clears a pending deletion, returning the user along with the
+// schedule that was cleared so it can be put back
+func (r *Repository) CancelDeletion(ctx context.Context, userID string) (*models.User, *DeletionSchedule, error) {
+	query := `
+		WITH previous AS (
+			SELECT id AS user_id, deletion_requested_at AS requested_at, deletion_scheduled_for AS scheduled_for
+			FROM users
+			WHERE id = $1 AND deletion_requested_at IS NOT NULL
+			FOR UPDATE
+		)
+		UPDATE users
+		SET deletion_requested_at = NULL, deletion_scheduled_for = NULL, updated_at = NOW()
+		FROM previous
+		WHERE users.id = previous.user_id
+		RETURNING ` + userColumns + `, previous.requested_at, previous.scheduled_for
+	`
+
+	var schedule DeletionSchedule
+	row := r.db.QueryRowContext(ctx, query, userID)
+	user, err := scanUser(db.WithExtra(row, &schedule.RequestedAt, &schedule.ScheduledFor))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("no pending deletion for user: %w", err)
+		}
+		return nil, nil, fmt.Errorf("error cancelling account deletion: %w", err)
+	}
+
+	return user, &schedule, nil
+}
+
+// RestoreDeletionSchedule puts back a deletion schedule, or clears the
+// pending deletion given nil. It undoes ScheduleDeletion and CancelDeletion
+// when the identity change that goes with them fails.
+func (r *Repository) RestoreDeletionSchedule(ctx context.Context, userID string, schedule *DeletionSchedule) error {
+	var requestedAt, scheduledFor *time.Time
+	if schedule != nil {
+		requestedAt, scheduledFor = &schedule.RequestedAt, &schedule.ScheduledFor
+	}
+
+	query := `
+		UPDATE users
+		SET deletion_requested_at = $2, deletion_scheduled_for = $3, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, requestedAt, scheduledFor); err != nil {
+		return fmt.Errorf("error restoring deletion schedule: %w", err)
+	}
+	return nil
+}
+
+// ListDueDeletions returns the users whose deletion grace period has ended
+func (r *Repository) ListDueDeletions(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id FROM users WHERE deletion_scheduled_for <= NOW()")
+	if err != nil {
+		return nil, fmt.Errorf("error listing due deletions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("error scanning user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *Repository) DeleteUser(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID); err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+	return nil
+}
+
+// GetAccountState returns the role and active suspension of a user
+func (r *Repository) GetAccountState(ctx context.Context, id string) (*auth.Account, error) {
+	return getAccountState(ctx, r.db, id)
+}
+
+// GetAccountStateTx is GetAccountState within tx
+func (r *Repository) GetAccountStateTx(ctx context.Context, tx *sql.Tx, id string) (*auth.Account, error) {
+	return getAccountState(ctx, tx, id)
+}
+
+func getAccountState(ctx context.Context, q db.Querier, id string) (*auth.Account, error) {
+	query := `
+		SELECT users.role, s.reason, s.expires_at
 		FROM users
-		WHERE id = $1
+		LEFT JOIN LATERAL (
+			SELECT reason, expires_at
+			FROM user_suspensions
+			WHERE user_suspensions.user_id = users.id AND ` + activeSuspensionFilter + `
+			ORDER BY created_at DESC
+			LIMIT 1
+		) s ON TRUE
+		WHERE users.id = $1
 	`
 
-	var user models.User
-	var firstName, lastName, profilePicture, bio sql.NullString
-	var interests []sql.NullString
-	var createdAt, updatedAt time.Time
+	var role string
+	var reason sql.NullString
+	var expiresAt sql.NullTime
+	err := q.QueryRowContext(ctx, query, id).Scan(&role, &reason, &expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("error querying account state: %w", err)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&user.ID, &user.Email, &firstName, &lastName, &profilePicture, &bio,
-		pq.Array(&interests), &createdAt, &updatedAt,
-	)
+	account := &auth.Account{Role: auth.Role(role)}
+	if reason.Valid {
+		account.Suspension = &auth.Suspension{Reason: reason.String}
+		if expiresAt.Valid {
+			account.Suspension.ExpiresAt = &expiresAt.Time
+		}
+	}
 
+	return account, nil
+}
+
+// SetUserRole changes the role of a user
+func (r *Repository) SetUserRole(ctx context.Context, id string, role auth.Role) (*models.User, error) {
+	query := `
+		UPDATE users
+		SET role = $2, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + userColumns + `
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, id, string(role)))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("user not found: %w", err)
 		}
-		return nil, fmt.Errorf("error querying user: %w", err)
+		return nil, fmt.Errorf("error updating user role: %w", err)
 	}
 
-	// Convert null strings to pointers
-	if firstName.Valid {
-		user.FirstName = &firstName.String
+	return user, nil
+}
+
+// suspensionColumns lists the user_suspensions columns read by scanSuspension, in order
+const suspensionColumns = `id, user_id, reason, issued_by, expires_at, lifted_at, lifted_by, created_at,
+		       lifted_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+// scanSuspension reads a user_suspensions row selected with suspensionColumns
+func scanSuspension(row db.RowScanner) (*models.Suspension, error) {
+	var suspension models.Suspension
+	var issuedBy, liftedBy sql.NullString
+	var expiresAt, liftedAt sql.NullTime
+	var createdAt time.Time
+
+	err := row.Scan(
+		&suspension.ID, &suspension.UserID, &suspension.Reason, &issuedBy,
+		&expiresAt, &liftedAt, &liftedBy, &createdAt, &suspension.Active,
+	)
+	if err != nil {
+		return nil, err
 	}
-	if lastName.Valid {
-		user.LastName = &lastName.String
+
+	if issuedBy.Valid {
+		suspension.IssuedBy = &issuedBy.String
 	}
-	if profilePicture.Valid {
-		user.ProfilePicture = &profilePicture.String
+	if liftedBy.Valid {
+		suspension.LiftedBy = &liftedBy.String
 	}
-	if bio.Valid {
-		user.Bio = &bio.String
+	if expiresAt.Valid {
+		formatted := expiresAt.Time.Format(time.RFC3339)
+		suspension.ExpiresAt = &formatted
 	}
-
-	// Convert sql.NullString array to string array
-	for _, i := range interests {
-		if i.Valid {
-			user.Interests = append(user.Interests, i.String)
-		}
+	if liftedAt.Valid {
+		formatted := liftedAt.Time.Format(time.RFC3339)
+		suspension.LiftedAt = &formatted
 	}
+	suspension.CreatedAt = createdAt.Format(time.RFC3339)
 
-	user.CreatedAt = createdAt.Format(time.RFC3339)
-	user.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &suspension, nil
+}
 
-	return &user, nil
+func (r *Repository) CreateSuspension(ctx context.Context, userID, reason, issuedBy string, expiresAt *time.Time) (*models.Suspension, error) {
+	return createSuspension(ctx, r.db, userID, reason, issuedBy, expiresAt)
 }
 
-func (r *Repository) CreateUser(ctx context.Context, id, email string) (*models.User, error) {
+// CreateSuspensionTx is CreateSuspension within tx
+func (r *Repository) CreateSuspensionTx(ctx context.Context, tx *sql.Tx, userID, reason, issuedBy string, expiresAt *time.Time) (*models.Suspension, error) {
+	return createSuspension(ctx, tx, userID, reason, issuedBy, expiresAt)
+}
+
+func createSuspension(ctx context.Context, q db.Querier, userID, reason, issuedBy string, expiresAt *time.Time) (*models.Suspension, error) {
 	query := `
-		INSERT INTO users (id, email)
-		VALUES ($1, $2)
-		RETURNING id, email, created_at, updated_at
+		INSERT INTO user_suspensions (id, user_id, reason, issued_by, expires_at)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4)
+		RETURNING ` + suspensionColumns + `
 	`
 
-	var user models.User
-	var createdAt, updatedAt time.Time
-
-	err := r.db.QueryRowContext(ctx, query, id, email).Scan(
-		&user.ID, &user.Email, &createdAt, &updatedAt,
-	)
-
+	suspension, err := scanSuspension(q.QueryRowContext(ctx, query, userID, reason, issuedBy, expiresAt))
 	if err != nil {
-		return nil, fmt.Errorf("error creating user: %w", err)
+		return nil, fmt.Errorf("error creating suspension: %w", err)
 	}
 
-	user.CreatedAt = createdAt.Format(time.RFC3339)
-	user.UpdatedAt = updatedAt.Format(time.RFC3339)
-
-	return &user, nil
+	return suspension, nil
 }
 
-func (r *Repository) UpdateProfile(ctx context.Context, userID string, input models.UpdateProfileInput) (*models.User, error) {
+// LiftSuspensions ends every active suspension of a user, returning how many there were
+func (r *Repository) LiftSuspensions(ctx context.Context, userID, liftedBy string) (int64, error) {
 	query := `
-		UPDATE users
-		SET 
-			first_name = COALESCE($2, first_name),
-			last_name = COALESCE($3, last_name),
-			profile_picture = COALESCE($4, profile_picture),
-			bio = COALESCE($5, bio),
-			interests = CASE WHEN $6::text[] IS NOT NULL THEN $6::text[] ELSE interests END,
-			updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, email, first_name, last_name, profile_picture, bio, interests, created_at, updated_at
+		UPDATE user_suspensions
+		SET lifted_at = NOW(), lifted_by = $2
+		WHERE user_id = $1 AND ` + activeSuspensionFilter + `
 	`
 
-	var user models.User
-	var firstName, lastName, profilePicture, bio sql.NullString
-	var interests []sql.NullString
-	var createdAt, updatedAt time.Time
+	result, err := r.db.ExecContext(ctx, query, userID, liftedBy)
+	if err != nil {
+		return 0, fmt.Errorf("error lifting suspensions: %w", err)
+	}
 
-	err := r.db.QueryRowContext(ctx, query, userID, input.FirstName, input.LastName,
-		input.ProfilePicture, input.Bio, pq.Array(input.Interests)).Scan(
-		&user.ID, &user.Email, &firstName, &lastName, &profilePicture, &bio,
-		pq.Array(&interests), &createdAt, &updatedAt,
-	)
+	return result.RowsAffected()
+}
 
+// ListSuspensions returns a page of a user's suspensions, newest first.
+// Lifted and expired suspensions are kept so they can be reviewed on appeal.
+func (r *Repository) ListSuspensions(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.Suspension], error) {
+	limit, err := args.Limit()
 	if err != nil {
-		return nil, fmt.Errorf("error updating profile: %w", err)
+		return nil, err
 	}
 
-	// Convert null strings to pointers
-	if firstName.Valid {
-		user.FirstName = &firstName.String
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
 	}
-	if lastName.Valid {
-		user.LastName = &lastName.String
+
+	query := `
+		SELECT ` + suspensionColumns + `, created_at
+		FROM user_suspensions
+		WHERE user_id = $1 AND (
+			$2::timestamptz IS NULL OR
+			created_at < $2 OR
+			(created_at = $2 AND id > $3)
+		)
+		ORDER BY created_at DESC, id
+		LIMIT $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, afterCreatedAt, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error listing suspensions: %w", err)
 	}
-	if profilePicture.Valid {
-		user.ProfilePicture = &profilePicture.String
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*models.Suspension, []interface{}, error) {
+		var createdAt time.Time
+		suspension, err := scanSuspension(db.WithExtra(rows, &createdAt))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning suspension row: %w", err)
+		}
+		return suspension, []interface{}{createdAt, suspension.ID}, nil
+	})
+}
+
+// publicFieldFilter matches users whose privacy_settings column makes a field
+// visible to everyone. Every user gets a row when they are provisioned, so
+// there are no defaults to fall back to; a missing row hides the field.
+func publicFieldFilter(column string) string {
+	return `EXISTS (
+			SELECT 1 FROM privacy_settings
+			WHERE privacy_settings.user_id = users.id AND privacy_settings.` + column + ` = 'public'
+		)`
+}
+
+// PublicFieldFilter matches users who share a profile field with everyone,
+// for queries in other packages that compare users by the field
+func PublicFieldFilter(field models.ProfileField) string {
+	return publicFieldFilter(strings.ToLower(string(field)))
+}
+
+// privacySettingsColumns lists the privacy_settings columns read by
+// scanPrivacySettings, in order
+const privacySettingsColumns = `email, last_name, bio, interests, travel_preferences, updated_at`
+
+// privacySettingsArgs lists the stored values of settings in the order of the
+// privacy_settings columns after user_id
+func privacySettingsArgs(settings *models.PrivacySettings) []interface{} {
+	return []interface{}{
+		visibilityFromModel(settings.Email), visibilityFromModel(settings.LastName),
+		visibilityFromModel(settings.Bio), visibilityFromModel(settings.Interests),
+		visibilityFromModel(settings.TravelPreferences),
 	}
-	if bio.Valid {
-		user.Bio = &bio.String
+}
+
+// scanPrivacySettings reads a privacy_settings row selected with privacySettingsColumns
+func scanPrivacySettings(row db.RowScanner) (*models.PrivacySettings, error) {
+	var email, lastName, bio, interests, travelPreferences string
+	var updatedAt time.Time
+
+	err := row.Scan(&email, &lastName, &bio, &interests, &travelPreferences, &updatedAt)
+	if err != nil {
+		return nil, err
 	}
 
-	// Convert sql.NullString array to string array
-	for _, i := range interests {
-		if i.Valid {
-			user.Interests = append(user.Interests, i.String)
+	formatted := updatedAt.Format(time.RFC3339)
+	return &models.PrivacySettings{
+		Email:             visibilityToModel(email),
+		LastName:          visibilityToModel(lastName),
+		Bio:               visibilityToModel(bio),
+		Interests:         visibilityToModel(interests),
+		TravelPreferences: visibilityToModel(travelPreferences),
+		UpdatedAt:         &formatted,
+	}, nil
+}
+
+// GetPrivacySettings returns the stored privacy settings of a user, or nil if
+// they have none
+func (r *Repository) GetPrivacySettings(ctx context.Context, userID string) (*models.PrivacySettings, error) {
+	query := `
+		SELECT ` + privacySettingsColumns + `
+		FROM privacy_settings
+		WHERE user_id = $1
+	`
+
+	settings, err := scanPrivacySettings(r.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil // Defaults apply
 		}
+		return nil, fmt.Errorf("error querying privacy settings: %w", err)
 	}
 
-	user.CreatedAt = createdAt.Format(time.RFC3339)
-	user.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return settings, nil
+}
 
-	return &user, nil
+// SavePrivacySettings stores the full set of privacy settings of a user
+func (r *Repository) SavePrivacySettings(ctx context.Context, userID string, settings *models.PrivacySettings) (*models.PrivacySettings, error) {
+	query := `
+		INSERT INTO privacy_settings (user_id, email, last_name, bio, interests, travel_preferences)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+			email = EXCLUDED.email,
+			last_name = EXCLUDED.last_name,
+			bio = EXCLUDED.bio,
+			interests = EXCLUDED.interests,
+			travel_preferences = EXCLUDED.travel_preferences,
+			updated_at = NOW()
+		RETURNING ` + privacySettingsColumns + `
+	`
+
+	args := append([]interface{}{userID}, privacySettingsArgs(settings)...)
+	saved, err := scanPrivacySettings(r.db.QueryRowContext(ctx, query, args...))
+	if err != nil {
+		return nil, fmt.Errorf("error saving privacy settings: %w", err)
+	}
+
+	return saved, nil
 }
 
 func (r *Repository) GetTravelPreferences(ctx context.Context, userID string) (*models.TravelPreferences, error) {
@@ -200,45 +1010,25 @@ func (r *Repository) GetTravelPreferences(ctx context.Context, userID string) (*
 }
 
 func (r *Repository) UpdateTravelPreferences(ctx context.Context, userID string, input models.UpdateTravelPreferencesInput) (*models.TravelPreferences, error) {
-	// First check if travel preferences exist for this user
-	var exists bool
-	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM travel_preferences WHERE user_id = $1)", userID).Scan(&exists)
-	if err != nil {
-		return nil, fmt.Errorf("error checking travel preferences: %w", err)
-	}
-
-	var query string
-	var params []interface{}
-
-	if !exists {
-		// Create new travel preferences
-		query = `
-			INSERT INTO travel_preferences (id, user_id, preferred_activities, travel_style, languages_spoken)
-			VALUES (gen_random_uuid(), $1, $2, $3, $4)
-			RETURNING id, user_id, preferred_activities, travel_style, languages_spoken, updated_at
-		`
-		params = []interface{}{userID, pq.Array(input.PreferredActivities), input.TravelStyle, pq.Array(input.LanguagesSpoken)}
-	} else {
-		// Update existing travel preferences
-		query = `
-			UPDATE travel_preferences
-			SET 
-				preferred_activities = CASE WHEN $2::text[] IS NOT NULL THEN $2::text[] ELSE preferred_activities END,
-				travel_style = COALESCE($3, travel_style),
-				languages_spoken = CASE WHEN $4::text[] IS NOT NULL THEN $4::text[] ELSE languages_spoken END,
-				updated_at = NOW()
-			WHERE user_id = $1
-			RETURNING id, user_id, preferred_activities, travel_style, languages_spoken, updated_at
-		`
-		params = []interface{}{userID, pq.Array(input.PreferredActivities), input.TravelStyle, pq.Array(input.LanguagesSpoken)}
-	}
+	// Fields left out of the input keep their current value
+	query := `
+		INSERT INTO travel_preferences (id, user_id, preferred_activities, travel_style, languages_spoken)
+		VALUES (gen_random_uuid(), $1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET
+			preferred_activities = COALESCE($2::text[], travel_preferences.preferred_activities),
+			travel_style = COALESCE($3, travel_preferences.travel_style),
+			languages_spoken = COALESCE($4::text[], travel_preferences.languages_spoken),
+			updated_at = NOW()
+		RETURNING id, user_id, preferred_activities, travel_style, languages_spoken, updated_at
+	`
+	params := []interface{}{userID, pq.Array(input.PreferredActivities), input.TravelStyle, pq.Array(input.LanguagesSpoken)}
 
 	var prefs models.TravelPreferences
 	var travelStyle sql.NullString
 	var preferredActivities, languagesSpoken []sql.NullString
 	var updatedAt time.Time
 
-	err = r.db.QueryRowContext(ctx, query, params...).Scan(
+	err := r.db.QueryRowContext(ctx, query, params...).Scan(
 		&prefs.ID, &prefs.UserID, pq.Array(&preferredActivities), &travelStyle,
 		pq.Array(&languagesSpoken), &updatedAt,
 	)
@@ -270,68 +1060,378 @@ func (r *Repository) UpdateTravelPreferences(ctx context.Context, userID string,
 	return &prefs, nil
 }
 
-func (r *Repository) SearchUsers(ctx context.Context, query string) ([]*models.User, error) {
+// SearchUsers ranks users by how well their indexed profile fields match
+// the query, treating each word as a prefix for type-ahead, plus how similar
+// their names and handle are to it so that misspelt names still match. A
+// handle starting with the query also matches, and an exact handle match
+// ranks first. Names match by trigram similarity once they clear pg_trgm's
+// similarity threshold (0.3 by default), which lets the trigram indexes be used.
+// Users the viewer can't see or has muted are left out.
+func (r *Repository) SearchUsers(ctx context.Context, viewerID, query string, args db.PageArgs) (*db.Page[*models.User], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows sort by exact handle match, then rank, then ID
+	var afterExact *bool
+	var afterRank float32
+	var afterID string
+	if err := args.DecodeAfter(&afterExact, &afterRank, &afterID); err != nil {
+		return nil, err
+	}
+
+	tsQuery := prefixTSQuery(query)
+	handle := strings.ToLower(NormalizeHandle(query))
+	if tsQuery == "" && handle == "" {
+		return &db.Page[*models.User]{Items: []*models.User{}, Cursors: []string{}}, nil
+	}
+
+	// The last name only takes part where the user's privacy settings make it public
+	publicLastName := publicFieldFilter("last_name")
+
 	sqlQuery := `
-		SELECT id, email, first_name, last_name, profile_picture, bio, interests, created_at, updated_at
-		FROM users
-		WHERE 
-			LOWER(email) LIKE LOWER($1) OR
-			LOWER(COALESCE(first_name, '')) LIKE LOWER($1) OR
-			LOWER(COALESCE(last_name, '')) LIKE LOWER($1) OR
-			LOWER(COALESCE(bio, '')) LIKE LOWER($1)
-		LIMIT 20
+		SELECT ` + userColumns + `, exact, rank
+		FROM (
+			SELECT
+				users.*,
+				COALESCE(LOWER(handle) = $3, FALSE) AS exact,
+				ts_rank(search_vector, to_tsquery('simple', $1)) + GREATEST(
+					similarity(COALESCE(first_name, ''), $3),
+					similarity(COALESCE(handle, ''), $3),
+					CASE WHEN ` + publicLastName + ` THEN similarity(COALESCE(last_name, ''), $3) ELSE 0 END,
+					CASE WHEN ` + publicLastName + `
+						THEN similarity(concat_ws(' ', first_name, last_name), $3) ELSE 0 END
+				) AS rank
+			FROM users
+			WHERE ` + VisibleToFilter("$8") + ` AND ` + NotMutedFilter("$8") + ` AND (
+				search_vector @@ to_tsquery('simple', $1) OR
+				LOWER(handle) LIKE $2 ESCAPE '\' OR
+				first_name % $3 OR
+				handle % $3 OR
+				(last_name % $3 AND ` + publicLastName + `)
+			)
+		) users
+		WHERE
+			$4::boolean IS NULL OR
+			exact < $4 OR
+			(exact = $4 AND rank < $5::real) OR
+			(exact = $4 AND rank = $5::real AND id > $6)
+		ORDER BY exact DESC, rank DESC, id
+		LIMIT $7
 	`
 
-	rows, err := r.db.QueryContext(ctx, sqlQuery, "%"+query+"%")
+	rows, err := r.db.QueryContext(ctx, sqlQuery, tsQuery, escapeLike(handle)+"%", handle,
+		afterExact, afterRank, afterID, limit+1, viewerID)
 	if err != nil {
 		return nil, fmt.Errorf("error searching users: %w", err)
 	}
 	defer rows.Close()
 
-	var users []*models.User
-	for rows.Next() {
-		var user models.User
-		var firstName, lastName, profilePicture, bio sql.NullString
-		var interests []sql.NullString
-		var createdAt, updatedAt time.Time
-
-		err := rows.Scan(
-			&user.ID, &user.Email, &firstName, &lastName, &profilePicture, &bio,
-			pq.Array(&interests), &createdAt, &updatedAt,
-		)
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*models.User, []interface{}, error) {
+		var exact bool
+		var rank float32
+		user, err := scanUser(db.WithExtra(rows, &exact, &rank))
 		if err != nil {
-			return nil, fmt.Errorf("error scanning user row: %w", err)
+			return nil, nil, fmt.Errorf("error scanning user row: %w", err)
 		}
+		return user, []interface{}{exact, rank, user.ID}, nil
+	})
+}
 
-		// Convert null strings to pointers
-		if firstName.Valid {
-			user.FirstName = &firstName.String
-		}
-		if lastName.Valid {
-			user.LastName = &lastName.String
+// maxFacetValues caps the number of values reported per traveler facet
+const maxFacetValues = 20
+
+// travelerMatches is a CTE named matches selecting the users that match the
+// parameters built by travelerFilterArgs ($1 to $8) and that the viewer can
+// see and hasn't muted, along with their facet values. Travel preferences and
+// interests only take part where the user's privacy settings make them public.
+func travelerMatches() string {
+	publicInterests := publicFieldFilter("interests")
+
+	return `
+		WITH matches AS (
+			SELECT
+				users.id AS user_id,
+				tp.travel_style AS style,
+				tp.languages_spoken AS languages,
+				tp.preferred_activities AS activities,
+				CASE WHEN ` + publicInterests + ` THEN users.interests END AS public_interests
+			FROM users
+			LEFT JOIN travel_preferences tp ON tp.user_id = users.id
+				AND ` + publicFieldFilter("travel_preferences") + `
+			WHERE ` + VisibleToFilter("$8") + `
+				AND ` + NotMutedFilter("$8") + `
+				AND ($1::text[] IS NULL OR tp.travel_style = ANY($1))
+				AND ($2::text[] IS NULL OR tp.languages_spoken && $2)
+				AND ($3::text[] IS NULL OR tp.languages_spoken @> $3)
+				AND ($4::text[] IS NULL OR tp.preferred_activities && $4)
+				AND ($5::text[] IS NULL OR tp.preferred_activities @> $5)
+				AND ($6::text[] IS NULL OR (users.interests && $6 AND ` + publicInterests + `))
+				AND ($7::text[] IS NULL OR (users.interests @> $7 AND ` + publicInterests + `))
+		)
+	`
+}
+
+// travelerFilterArgs flattens a traveler filter and the searching viewer into
+// the parameters of travelerMatches. Empty lists are treated like absent ones.
+func travelerFilterArgs(viewerID string, filter *models.TravelerFilter) []interface{} {
+	if filter == nil {
+		filter = &models.TravelerFilter{}
+	}
+
+	list := func(values []string) interface{} {
+		if len(values) == 0 {
+			return nil
 		}
-		if profilePicture.Valid {
-			user.ProfilePicture = &profilePicture.String
+		return pq.Array(values)
+	}
+	anyOf := func(f *models.ArrayFilter) interface{} {
+		if f == nil {
+			return nil
 		}
-		if bio.Valid {
-			user.Bio = &bio.String
+		return list(f.AnyOf)
+	}
+	allOf := func(f *models.ArrayFilter) interface{} {
+		if f == nil {
+			return nil
 		}
+		return list(f.AllOf)
+	}
+
+	return []interface{}{
+		list(filter.TravelStyles),
+		anyOf(filter.Languages), allOf(filter.Languages),
+		anyOf(filter.Activities), allOf(filter.Activities),
+		anyOf(filter.Interests), allOf(filter.Interests),
+		viewerID,
+	}
+}
+
+// SearchTravelers returns a page of the users matching a traveler filter,
+// newest members first
+func (r *Repository) SearchTravelers(ctx context.Context, viewerID string, filter *models.TravelerFilter, args db.PageArgs) (*db.Page[*models.User], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterCreatedAt *time.Time
+	var afterID string
+	if err := args.DecodeAfter(&afterCreatedAt, &afterID); err != nil {
+		return nil, err
+	}
 
-		// Convert sql.NullString array to string array
-		for _, i := range interests {
-			if i.Valid {
-				user.Interests = append(user.Interests, i.String)
-			}
+	query := travelerMatches() + `
+		SELECT ` + userColumns + `, users.created_at
+		FROM users
+		JOIN matches ON matches.user_id = users.id
+		WHERE
+			$9::timestamptz IS NULL OR
+			users.created_at < $9 OR
+			(users.created_at = $9 AND users.id > $10)
+		ORDER BY users.created_at DESC, users.id
+		LIMIT $11
+	`
+
+	params := append(travelerFilterArgs(viewerID, filter), afterCreatedAt, afterID, limit+1)
+	rows, err := r.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error searching travelers: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*models.User, []interface{}, error) {
+		var createdAt time.Time
+		user, err := scanUser(db.WithExtra(rows, &createdAt))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning user row: %w", err)
 		}
+		return user, []interface{}{createdAt, user.ID}, nil
+	})
+}
+
+// CountTravelers returns how many users match a traveler filter
+func (r *Repository) CountTravelers(ctx context.Context, viewerID string, filter *models.TravelerFilter) (int, error) {
+	query := travelerMatches() + `SELECT COUNT(DISTINCT user_id) FROM matches`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, travelerFilterArgs(viewerID, filter)...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error counting travelers: %w", err)
+	}
+	return count, nil
+}
+
+// TravelerFacets counts, for each facet, how many of the users matching a
+// traveler filter have each value, keeping the most common values
+func (r *Repository) TravelerFacets(ctx context.Context, viewerID string, filter *models.TravelerFilter) (*models.TravelerFacets, error) {
+	query := travelerMatches() + `
+		SELECT facet, value, count
+		FROM (
+			SELECT facet, value, count,
+				ROW_NUMBER() OVER (PARTITION BY facet ORDER BY count DESC, value) AS position
+			FROM (
+				SELECT 'travel_style' AS facet, style AS value, COUNT(DISTINCT user_id) AS count
+				FROM matches WHERE style IS NOT NULL GROUP BY style
+				UNION ALL
+				SELECT 'language', value, COUNT(DISTINCT user_id)
+				FROM matches, unnest(languages) AS value GROUP BY value
+				UNION ALL
+				SELECT 'activity', value, COUNT(DISTINCT user_id)
+				FROM matches, unnest(activities) AS value GROUP BY value
+				UNION ALL
+				SELECT 'interest', value, COUNT(DISTINCT user_id)
+				FROM matches, unnest(public_interests) AS value GROUP BY value
+			) counts
+		) ranked
+		WHERE position <= $9
+		ORDER BY facet, position
+	`
+
+	params := append(travelerFilterArgs(viewerID, filter), maxFacetValues)
+	rows, err := r.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, fmt.Errorf("error counting traveler facets: %w", err)
+	}
+	defer rows.Close()
 
-		user.CreatedAt = createdAt.Format(time.RFC3339)
-		user.UpdatedAt = updatedAt.Format(time.RFC3339)
+	facets := &models.TravelerFacets{
+		TravelStyles: []*models.FacetCount{},
+		Languages:    []*models.FacetCount{},
+		Activities:   []*models.FacetCount{},
+		Interests:    []*models.FacetCount{},
+	}
+	for rows.Next() {
+		var facet string
+		var count models.FacetCount
+		if err := rows.Scan(&facet, &count.Value, &count.Count); err != nil {
+			return nil, fmt.Errorf("error scanning facet row: %w", err)
+		}
 
-		users = append(users, &user)
+		switch facet {
+		case "travel_style":
+			facets.TravelStyles = append(facets.TravelStyles, &count)
+		case "language":
+			facets.Languages = append(facets.Languages, &count)
+		case "activity":
+			facets.Activities = append(facets.Activities, &count)
+		case "interest":
+			facets.Interests = append(facets.Interests, &count)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return users, nil
+	return facets, nil
+}
+
+// GetCurrentLocation returns the location a user shares, or nil if they don't share one
+func (r *Repository) GetCurrentLocation(ctx context.Context, userID string) (*models.Location, error) {
+	query := `
+		SELECT latitude, longitude, updated_at
+		FROM user_locations
+		WHERE user_id = $1
+	`
+
+	var location models.Location
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(&location.Latitude, &location.Longitude, &updatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error querying current location: %w", err)
+	}
+
+	location.UpdatedAt = updatedAt.Format(time.RFC3339)
+	return &location, nil
+}
+
+// SaveCurrentLocation starts sharing a location for the user or replaces the one they share
+func (r *Repository) SaveCurrentLocation(ctx context.Context, userID string, point geo.Point) error {
+	query := `
+		INSERT INTO user_locations (user_id, latitude, longitude, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			latitude = EXCLUDED.latitude,
+			longitude = EXCLUDED.longitude,
+			updated_at = EXCLUDED.updated_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, point.Latitude, point.Longitude); err != nil {
+		return fmt.Errorf("error saving current location: %w", err)
+	}
+	return nil
+}
+
+// DeleteCurrentLocation stops sharing the user's current location
+func (r *Repository) DeleteCurrentLocation(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM user_locations WHERE user_id = $1", userID); err != nil {
+		return fmt.Errorf("error deleting current location: %w", err)
+	}
+	return nil
+}
+
+// NearbyTravelers returns a page of the users sharing a location updated
+// since sharedSince within radiusKm of origin, nearest first, leaving out
+// users the viewer can't see or has muted. The bounding box lets the
+// user_locations index discard far away rows before the exact haversine
+// distance, which matches geo.Distance, is computed.
+func (r *Repository) NearbyTravelers(ctx context.Context, viewerID string, origin geo.Point, radiusKm float64, sharedSince time.Time, args db.PageArgs) (*db.Page[*NearbyTraveler], error) {
+	limit, err := args.Limit()
+	if err != nil {
+		return nil, err
+	}
+
+	var afterDistance *float64
+	var afterID string
+	if err := args.DecodeAfter(&afterDistance, &afterID); err != nil {
+		return nil, err
+	}
+
+	box := geo.BoundingBox(origin, radiusKm)
+	query := `
+		SELECT ` + userColumns + `, distance
+		FROM (
+			SELECT
+				users.*,
+				2 * 6371.0 * ASIN(LEAST(1, SQRT(
+					POWER(SIN(RADIANS(l.latitude - $1) / 2), 2) +
+					COS(RADIANS($1)) * COS(RADIANS(l.latitude)) * POWER(SIN(RADIANS(l.longitude - $2) / 2), 2)
+				))) AS distance
+			FROM users
+			JOIN user_locations l ON l.user_id = users.id
+			WHERE ` + VisibleToFilter("$3") + ` AND
+				` + NotMutedFilter("$3") + ` AND
+				users.id <> $3 AND
+				l.updated_at >= $4 AND
+				l.latitude BETWEEN $5 AND $6 AND
+				l.longitude BETWEEN $7 AND $8
+		) users
+		WHERE distance <= $9 AND (
+			$10::double precision IS NULL OR
+			distance > $10 OR
+			(distance = $10 AND id > $11)
+		)
+		ORDER BY distance, id
+		LIMIT $12
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, origin.Latitude, origin.Longitude, viewerID, sharedSince,
+		box.MinLatitude, box.MaxLatitude, box.MinLongitude, box.MaxLongitude,
+		radiusKm, afterDistance, afterID, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("error searching nearby travelers: %w", err)
+	}
+	defer rows.Close()
+
+	return db.CollectPage(rows, args, limit, func(rows *sql.Rows) (*NearbyTraveler, []interface{}, error) {
+		var distance float64
+		user, err := scanUser(db.WithExtra(rows, &distance))
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning user row: %w", err)
+		}
+		return &NearbyTraveler{User: user, DistanceKm: distance}, []interface{}{distance, user.ID}, nil
+	})
 }
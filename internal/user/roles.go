@@ -0,0 +1,18 @@
+package user
+
+import (
+	"strings"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// RoleFromModel converts a GraphQL role to the value stored in users.role
+func RoleFromModel(role models.Role) auth.Role {
+	return auth.Role(strings.ToLower(string(role)))
+}
+
+// roleToModel converts a stored role to its GraphQL representation
+func roleToModel(role auth.Role) models.Role {
+	return models.Role(strings.ToUpper(string(role)))
+}
@@ -0,0 +1,37 @@
+package user
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxSearchTerms caps the number of words of a search query that are used
+const maxSearchTerms = 8
+
+// prefixTSQuery turns free text into a to_tsquery expression matching every
+// word as a prefix. Only letters and digits are kept, which both mirrors how
+// the simple text search parser splits words and leaves no tsquery operators
+// or quotes for a caller to inject.
+func prefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// escapeLike makes a string match itself literally in a LIKE pattern
+// declared with ESCAPE '\'
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
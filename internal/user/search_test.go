@@ -0,0 +1,49 @@
+package user
+
+import "testing"
+
+func TestPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"empty", "", ""},
+		{"single word", "Lisbon", "lisbon:*"},
+		{"several words", "  Jane   Doe ", "jane:* & doe:*"},
+		{"operators are dropped", "a & b | !c <-> d:*", "a:* & b:* & c:* & d:*"},
+		{"quotes split words", "o'brien \"x\"", "o:* & brien:* & x:*"},
+		{"only punctuation", "&|!():*'", ""},
+		{"unicode letters are kept", "São Paulo", "são:* & paulo:*"},
+		{"digits are kept", "route 66", "route:* & 66:*"},
+		{"extra words are ignored", "a b c d e f g h i j", "a:* & b:* & c:* & d:* & e:* & f:* & g:* & h:*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := prefixTSQuery(tt.query); got != tt.want {
+				t.Errorf("prefixTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"50%", `50\%`},
+		{"snake_case", `snake\_case`},
+		{`back\slash`, `back\\slash`},
+		{`%_\`, `\%\_\\`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLike(tt.in); got != tt.want {
+			t.Errorf("escapeLike(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
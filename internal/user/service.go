@@ -2,10 +2,15 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/config"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
 	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/storage"
 	ory "github.com/ory/client-go"
 	kratosclient "github.com/ory/kratos-client-go"
 )
@@ -13,12 +18,14 @@ import (
 type Service struct {
 	repo   *Repository
 	config *config.Config
+	media  storage.BlobStore
 }
 
-func NewService(repo *Repository, cfg *config.Config) *Service {
+func NewService(repo *Repository, cfg *config.Config, media storage.BlobStore) *Service {
 	return &Service{
 		repo:   repo,
 		config: cfg,
+		media:  media,
 	}
 }
 
@@ -26,64 +33,149 @@ func (s *Service) GetUserByID(ctx context.Context, id string) (*models.User, err
 	return s.repo.GetUserByID(ctx, id)
 }
 
+// GetVisibleUserByID returns a user as the viewer may see them. Users hidden
+// from the viewer, including by a block, are reported as not found.
+func (s *Service) GetVisibleUserByID(ctx context.Context, viewerID, id string) (*models.User, error) {
+	return s.repo.GetVisibleUserByID(ctx, viewerID, id)
+}
+
+// GetVisibleUsersByIDs returns the users among ids that the viewer may see,
+// in the order of ids
+func (s *Service) GetVisibleUsersByIDs(ctx context.Context, viewerID string, ids []string) ([]*models.User, error) {
+	users, err := s.repo.GetVisibleUsersByIDs(ctx, viewerID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	ordered := make([]*models.User, 0, len(users))
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			ordered = append(ordered, user)
+		}
+	}
+	return ordered, nil
+}
+
 func (s *Service) GetOrCreateUser(ctx context.Context, id string) (*models.User, error) {
 	// Try to get existing user
 	user, err := s.repo.GetUserByID(ctx, id)
 	if err == nil {
 		return user, nil
 	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
 
-	// User not found, get email from Kratos and create user
-	email, err := s.getUserEmailFromKratos(ctx, id)
+	// User not found, provision it from the Kratos identity
+	traits, err := s.getIdentityTraits(ctx, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info from Kratos: %w", err)
 	}
 
-	return s.repo.CreateUser(ctx, id, email)
+	return s.ProvisionUser(ctx, id, traits)
 }
 
-func (s *Service) getUserEmailFromKratos(ctx context.Context, id string) (string, error) {
-	client := ory.NewAPIClient(&ory.Configuration{
+// ProvisionUser creates the local users row for a Kratos identity, storing the
+// default privacy settings with it. It is idempotent, so the registration
+// webhook and lazy provisioning can race safely.
+func (s *Service) ProvisionUser(ctx context.Context, id string, traits *IdentityTraits) (*models.User, error) {
+	return s.repo.CreateUserIfNotExists(ctx, id, traits.Email,
+		optionalString(traits.Name.First), optionalString(traits.Name.Last), defaultPrivacySettings())
+}
+
+func (s *Service) getIdentityTraits(ctx context.Context, id string) (*IdentityTraits, error) {
+	// The session in context already carries the caller's identity
+	if session, ok := auth.GetUserFromContext(ctx); ok && session.Identity != nil && session.Identity.Id == id {
+		return ParseTraits(session.Identity.Traits)
+	}
+
+	identity, _, err := s.kratosAdmin().IdentityAPI.GetIdentity(ctx, id).Execute()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity from Kratos: %w", err)
+	}
+
+	return ParseTraits(identity.Traits)
+}
+
+// LoadAccount implements auth.AccountLoader. Identities that don't have a
+// users row yet get the default role.
+func (s *Service) LoadAccount(ctx context.Context, userID string) (*auth.Account, error) {
+	account, err := s.repo.GetAccountState(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &auth.Account{Role: auth.RoleUser}, nil
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// SetUserRole changes the role of a user
+func (s *Service) SetUserRole(ctx context.Context, userID string, role models.Role) (*models.User, error) {
+	return s.repo.SetUserRole(ctx, userID, RoleFromModel(role))
+}
+
+// kratosAdmin creates a client for the Kratos admin API
+func (s *Service) kratosAdmin() *ory.APIClient {
+	return ory.NewAPIClient(&ory.Configuration{
 		Servers: []ory.ServerConfiguration{
 			{
 				URL: s.config.KratosAdminURL,
 			},
 		},
 	})
+}
 
-	identity, _, err := client.IdentityAPI.GetIdentity(ctx, id).Execute()
-	if err != nil {
-		return "", fmt.Errorf("failed to get identity from Kratos: %w", err)
-	}
-
-	// Extract email from traits
-	traits, ok := identity.Traits.(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid traits format")
+// UpdateProfile updates a user's profile. Setting a profile picture URL
+// replaces an uploaded picture, whose blob is then deleted.
+func (s *Service) UpdateProfile(ctx context.Context, userID string, input models.UpdateProfileInput) (*models.User, error) {
+	var previousKey *string
+	if input.ProfilePicture != nil {
+		current, err := s.repo.GetUserByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		previousKey = current.ProfilePictureKey
 	}
 
-	email, ok := traits["email"].(string)
-	if !ok {
-		return "", fmt.Errorf("email not found in traits")
+	user, err := s.repo.UpdateProfile(ctx, userID, input)
+	if err != nil {
+		return nil, err
 	}
 
-	return email, nil
-}
-
-func (s *Service) UpdateProfile(ctx context.Context, userID string, input models.UpdateProfileInput) (*models.User, error) {
-	return s.repo.UpdateProfile(ctx, userID, input)
+	s.deleteProfilePicture(ctx, previousKey)
+	return user, nil
 }
 
 func (s *Service) GetTravelPreferences(ctx context.Context, userID string) (*models.TravelPreferences, error) {
 	return s.repo.GetTravelPreferences(ctx, userID)
 }
 
+// GetUserProfile bundles a user with their travel preferences
+func (s *Service) GetUserProfile(ctx context.Context, user *models.User) (*models.UserProfile, error) {
+	prefs, err := s.repo.GetTravelPreferences(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserProfile{
+		User:              user,
+		TravelPreferences: prefs,
+	}, nil
+}
+
 func (s *Service) UpdateTravelPreferences(ctx context.Context, userID string, input models.UpdateTravelPreferencesInput) (*models.TravelPreferences, error) {
 	return s.repo.UpdateTravelPreferences(ctx, userID, input)
 }
 
-func (s *Service) SearchUsers(ctx context.Context, query string) ([]*models.User, error) {
-	return s.repo.SearchUsers(ctx, query)
+func (s *Service) SearchUsers(ctx context.Context, viewerID, query string, args db.PageArgs) (*db.Page[*models.User], error) {
+	return s.repo.SearchUsers(ctx, viewerID, query, args)
 }
 
 func (s *Service) GetUserSession(ctx context.Context, sessionToken string) (*kratosclient.Session, error) {
@@ -0,0 +1,121 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/auth"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// maxSuspensionHours caps timed suspensions at ten years, well short of
+// overflowing a time.Duration; longer ones should be indefinite
+const maxSuspensionHours = 10 * 365 * 24
+
+// SuspendUser suspends an account for durationHours, or indefinitely when no
+// duration is given. Moderators can only suspend users whose role is below
+// their own, which also rules out suspending themselves.
+func (s *Service) SuspendUser(ctx context.Context, moderatorID string, moderatorRole auth.Role, input models.SuspendUserInput) (*models.Suspension, error) {
+	reason, expiresAt, err := s.checkSuspension(ctx, nil, moderatorID, moderatorRole, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateSuspension(ctx, input.UserID, reason, moderatorID, expiresAt)
+}
+
+// SuspendUserTx is SuspendUser within tx, for callers recording the
+// suspension together with writes of their own
+func (s *Service) SuspendUserTx(ctx context.Context, tx *sql.Tx, moderatorID string, moderatorRole auth.Role, input models.SuspendUserInput) (*models.Suspension, error) {
+	reason, expiresAt, err := s.checkSuspension(ctx, tx, moderatorID, moderatorRole, input)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateSuspensionTx(ctx, tx, input.UserID, reason, moderatorID, expiresAt)
+}
+
+// checkSuspension validates a suspension, returning its reason and expiry.
+// The suspended user's role is read in tx unless it is nil.
+func (s *Service) checkSuspension(ctx context.Context, tx *sql.Tx, moderatorID string, moderatorRole auth.Role, input models.SuspendUserInput) (string, *time.Time, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return "", nil, errors.New("a suspension reason is required")
+	}
+
+	var expiresAt *time.Time
+	if input.DurationHours != nil {
+		if *input.DurationHours < 1 || *input.DurationHours > maxSuspensionHours {
+			return "", nil, fmt.Errorf("durationHours must be between 1 and %d, leave it out to suspend indefinitely", maxSuspensionHours)
+		}
+		t := time.Now().Add(time.Duration(*input.DurationHours) * time.Hour)
+		expiresAt = &t
+	}
+
+	if err := s.requireOutranks(ctx, tx, moderatorID, moderatorRole, input.UserID, "suspend"); err != nil {
+		return "", nil, err
+	}
+
+	return reason, expiresAt, nil
+}
+
+// UnsuspendUser lifts every active suspension of a user, reporting whether
+// there was anything to lift
+func (s *Service) UnsuspendUser(ctx context.Context, moderatorID, userID string) (bool, error) {
+	lifted, err := s.repo.LiftSuspensions(ctx, userID, moderatorID)
+	if err != nil {
+		return false, err
+	}
+	return lifted > 0, nil
+}
+
+// SuspensionHistory returns a page of the suspensions a user has received
+func (s *Service) SuspensionHistory(ctx context.Context, userID string, args db.PageArgs) (*db.Page[*models.Suspension], error) {
+	return s.repo.ListSuspensions(ctx, userID, args)
+}
+
+// RemoveProfileContent clears the free-form parts of a user's profile, their
+// bio, interests and profile picture, within tx on behalf of a moderator. Like
+// suspensions it is limited to users whose role is below the moderator's. The
+// picture's blobs are only deleted by the returned function, which is meant to
+// be called once tx has committed.
+func (s *Service) RemoveProfileContent(ctx context.Context, tx *sql.Tx, moderatorID string, moderatorRole auth.Role, userID string) (*models.User, func(), error) {
+	if err := s.requireOutranks(ctx, tx, moderatorID, moderatorRole, userID, "moderate"); err != nil {
+		return nil, nil, err
+	}
+
+	user, pictureKey, err := s.repo.ClearProfileContent(ctx, tx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, func() { s.deleteProfilePicture(ctx, pictureKey) }, nil
+}
+
+// requireOutranks rejects a moderator acting on a user unless the user's role
+// is below their own, which also rules out acting on themselves. The user's
+// role is read in tx unless it is nil.
+func (s *Service) requireOutranks(ctx context.Context, tx *sql.Tx, moderatorID string, moderatorRole auth.Role, userID, action string) error {
+	var target *auth.Account
+	var err error
+	if tx != nil {
+		target, err = s.repo.GetAccountStateTx(ctx, tx, userID)
+	} else {
+		target, err = s.repo.GetAccountState(ctx, userID)
+	}
+	if err != nil {
+		return err
+	}
+	if userID == moderatorID || target.Role.Includes(moderatorRole) {
+		return &auth.Error{
+			Code:    auth.CodeForbidden,
+			Message: "cannot " + action + " a user with an equal or higher role",
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+	ory "github.com/ory/client-go"
+)
+
+// Authority names the side that wins when a mirrored field differs between
+// the users table and the Kratos identity traits
+type Authority string
+
+const (
+	AuthorityKratos Authority = "kratos"
+	AuthorityLocal  Authority = "local"
+)
+
+// FieldAuthority records which side is authoritative for each users column
+// mirrored from identity traits. Email is the Kratos login identifier, so
+// Kratos owns it. Names are edited through updateProfile as well as the
+// settings flow; settings changes reach the users table straight away via the
+// settings webhook, so the local row is the one to trust when they disagree.
+var FieldAuthority = map[string]Authority{
+	"email":      AuthorityKratos,
+	"first_name": AuthorityLocal,
+	"last_name":  AuthorityLocal,
+}
+
+// identityPageSize is the number of identities fetched per admin API call
+const identityPageSize = 250
+
+// ReconcileReport summarises one reconciliation run
+type ReconcileReport struct {
+	Checked            int
+	UpdatedLocal       []string
+	UpdatedKratos      []string
+	Failed             []string
+	OrphanedRows       []string
+	OrphanedIdentities []string
+}
+
+func (r *ReconcileReport) String() string {
+	return fmt.Sprintf(
+		"checked=%d updated_local=%d updated_kratos=%d failed=%d orphaned_rows=%v orphaned_identities=%v",
+		r.Checked, len(r.UpdatedLocal), len(r.UpdatedKratos), len(r.Failed),
+		r.OrphanedRows, r.OrphanedIdentities,
+	)
+}
+
+// SyncIdentity applies identity traits that were just changed in a Kratos
+// settings flow to the users row, provisioning the row if it is missing. The
+// change is an explicit edit on the Kratos side, so it wins for every field.
+func (s *Service) SyncIdentity(ctx context.Context, id string, traits *IdentityTraits) (*models.User, error) {
+	user, err := s.repo.SyncIdentityTraits(ctx, id, traits.Email,
+		optionalString(traits.Name.First), optionalString(traits.Name.Last))
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.ProvisionUser(ctx, id, traits)
+	}
+	return user, err
+}
+
+// ReconcileIdentities compares every users row with its Kratos identity,
+// resolving differences according to FieldAuthority, and reports rows without
+// an identity and identities without a row. Orphans are only reported, never
+// deleted.
+func (s *Service) ReconcileIdentities(ctx context.Context) (*ReconcileReport, error) {
+	client := s.kratosAdmin()
+
+	identities, err := listIdentities(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	local, err := s.repo.ListIdentityFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReconcileReport{}
+	for _, identity := range identities {
+		row, ok := local[identity.Id]
+		if !ok {
+			report.OrphanedIdentities = append(report.OrphanedIdentities, identity.Id)
+			continue
+		}
+		delete(local, identity.Id)
+		report.Checked++
+
+		if err := s.reconcileIdentity(ctx, client, identity, row, report); err != nil {
+			log.Printf("Failed to reconcile identity %s: %v", identity.Id, err)
+			report.Failed = append(report.Failed, identity.Id)
+		}
+	}
+
+	// Whatever is left has no Kratos identity
+	for id := range local {
+		report.OrphanedRows = append(report.OrphanedRows, id)
+	}
+	sort.Strings(report.OrphanedRows)
+
+	return report, nil
+}
+
+// reconcileIdentity brings one users row and its identity back in line
+func (s *Service) reconcileIdentity(ctx context.Context, client *ory.APIClient, identity ory.Identity, row identityFields, report *ReconcileReport) error {
+	traits, err := ParseTraits(identity.Traits)
+	if err != nil {
+		return err
+	}
+
+	remote := map[string]*string{
+		"email":      &traits.Email,
+		"first_name": optionalString(traits.Name.First),
+		"last_name":  optionalString(traits.Name.Last),
+	}
+	merged := map[string]*string{
+		"email":      &row.Email,
+		"first_name": row.FirstName,
+		"last_name":  row.LastName,
+	}
+
+	var updateLocal, updateKratos bool
+	for field, authority := range FieldAuthority {
+		if equalOptional(remote[field], merged[field]) {
+			continue
+		}
+
+		// A missing local value never overrides what Kratos has
+		if authority == AuthorityKratos || merged[field] == nil {
+			merged[field] = remote[field]
+			updateLocal = true
+		} else {
+			updateKratos = true
+		}
+	}
+
+	if updateLocal {
+		if _, err := s.repo.SyncIdentityTraits(ctx, identity.Id, *merged["email"],
+			merged["first_name"], merged["last_name"]); err != nil {
+			return err
+		}
+		report.UpdatedLocal = append(report.UpdatedLocal, identity.Id)
+	}
+
+	if updateKratos {
+		name := map[string]interface{}{}
+		if first := merged["first_name"]; first != nil {
+			name["first"] = *first
+		}
+		if last := merged["last_name"]; last != nil {
+			name["last"] = *last
+		}
+
+		patch := ory.NewJsonPatch("add", "/traits/name")
+		patch.Value = name
+		if _, _, err := client.IdentityAPI.PatchIdentity(ctx, identity.Id).
+			JsonPatch([]ory.JsonPatch{*patch}).
+			Execute(); err != nil {
+			return fmt.Errorf("failed to patch identity traits: %w", err)
+		}
+		report.UpdatedKratos = append(report.UpdatedKratos, identity.Id)
+	}
+
+	return nil
+}
+
+// listIdentities pages through every identity in Kratos
+func listIdentities(ctx context.Context, client *ory.APIClient) ([]ory.Identity, error) {
+	var identities []ory.Identity
+	pageToken := ""
+
+	for {
+		req := client.IdentityAPI.ListIdentities(ctx).PageSize(identityPageSize)
+		if pageToken != "" {
+			req = req.PageToken(pageToken)
+		}
+
+		page, resp, err := req.Execute()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list identities from Kratos: %w", err)
+		}
+		identities = append(identities, page...)
+
+		pageToken = nextPageToken(resp)
+		if pageToken == "" || len(page) == 0 {
+			return identities, nil
+		}
+	}
+}
+
+// nextPageToken extracts the page_token of the rel="next" Link header that
+// Kratos uses for keyset pagination
+func nextPageToken(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+
+	for _, link := range strings.Split(resp.Header.Get("Link"), ",") {
+		parts := strings.Split(link, ";")
+		if len(parts) < 2 || !strings.Contains(parts[1], `rel="next"`) {
+			continue
+		}
+
+		target, err := url.Parse(strings.Trim(strings.TrimSpace(parts[0]), "<>"))
+		if err != nil {
+			return ""
+		}
+		return target.Query().Get("page_token")
+	}
+
+	return ""
+}
+
+// equalOptional compares two nullable strings
+func equalOptional(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
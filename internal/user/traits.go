@@ -0,0 +1,42 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// IdentityTraits mirrors the traits defined in kratos/identity.schema.json
+type IdentityTraits struct {
+	Email string `json:"email"`
+	Name  struct {
+		First string `json:"first"`
+		Last  string `json:"last"`
+	} `json:"name"`
+}
+
+// ParseTraits decodes the untyped traits of a Kratos identity
+func ParseTraits(raw interface{}) (*IdentityTraits, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid traits format: %w", err)
+	}
+
+	var traits IdentityTraits
+	if err := json.Unmarshal(data, &traits); err != nil {
+		return nil, fmt.Errorf("invalid traits format: %w", err)
+	}
+
+	if traits.Email == "" {
+		return nil, fmt.Errorf("email not found in traits")
+	}
+
+	return &traits, nil
+}
+
+// optionalString returns nil for empty strings so they are stored as NULL
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
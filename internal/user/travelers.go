@@ -0,0 +1,32 @@
+package user
+
+import (
+	"context"
+
+	"github.com/karthickgandhiTV/travel-social-backend/internal/db"
+	"github.com/karthickgandhiTV/travel-social-backend/internal/graph/models"
+)
+
+// SearchTravelers returns a page of the users matching a traveler filter.
+// Within one list filter, anyOf matches users with at least one of the values
+// and allOf users with every value; separate filters must all match. Users
+// the viewer can't see or has muted are left out.
+func (s *Service) SearchTravelers(ctx context.Context, viewerID string, filter *models.TravelerFilter, args db.PageArgs) (*db.Page[*models.User], error) {
+	return s.repo.SearchTravelers(ctx, viewerID, filter, args)
+}
+
+// TravelerFacets returns the number of users matching a traveler filter and
+// how common each travel style, language, activity and interest is among them
+func (s *Service) TravelerFacets(ctx context.Context, viewerID string, filter *models.TravelerFilter) (int, *models.TravelerFacets, error) {
+	total, err := s.repo.CountTravelers(ctx, viewerID, filter)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	facets, err := s.repo.TravelerFacets(ctx, viewerID, filter)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return total, facets, nil
+}